@@ -1,7 +1,6 @@
 package invader
 
 import (
-	"math/rand"
 	"strconv"
 )
 
@@ -13,7 +12,19 @@ const (
 	Trapped
 )
 
-var counterID uint
+// String returns the name of the state, as written in snapshots.
+func (s AlienState) String() string {
+	switch s {
+	case Alive:
+		return "alive"
+	case Killed:
+		return "killed"
+	case Trapped:
+		return "trapped"
+	}
+
+	return "unknown"
+}
 
 type Alien struct {
 	ID          uint
@@ -21,10 +32,10 @@ type Alien struct {
 	State       AlienState
 }
 
-func NewAlien(c *City) *Alien {
-	counterID++
+// NewAlien creates the alien with the given ID and puts it inside the city.
+func NewAlien(id uint, c *City) *Alien {
 	a := &Alien{
-		ID:          counterID,
+		ID:          id,
 		CurrentCity: c,
 	}
 
@@ -38,7 +49,11 @@ func (a *Alien) Kill() {
 }
 
 func (a *Alien) Name() string {
-	return strconv.FormatUint(uint64(a.ID), 10)
+	return alienName(a.ID)
+}
+
+func alienName(id uint) string {
+	return strconv.FormatUint(uint64(id), 10)
 }
 
 // Move moves the Alien in a given direction. It returns a reference to an Alien
@@ -63,12 +78,12 @@ func (a *Alien) Move(dir Direction) (occupy *Alien, ok bool) {
 	return
 }
 
-// RandomMove makes the Alien move in a random available direction.
+// RandomMove makes the Alien move in an available direction drawn from r.
 // It returns a reference to an Alien occupying the city in the direction of the move (if any)
 // and a boolean indicating whether the move was successful.
-func (a *Alien) RandomMove() (occupy *Alien, ok bool) {
+func (a *Alien) RandomMove(r *Rand) (occupy *Alien, ok bool) {
 	if dirs := a.CurrentCity.GetAvailableDirections(); len(dirs) > 0 {
-		ndir := rand.Intn(len(dirs))
+		ndir := r.Intn(len(dirs))
 		return a.Move(dirs[ndir])
 	}
 
@@ -8,9 +8,9 @@ import (
 
 func TestNewAlien(t *testing.T) {
 	city := NewCity("TestCity")
-	alien := NewAlien(city)
+	alien := NewAlien(1, city)
 
-	require.Greater(t, alien.ID, uint(0))
+	require.Equal(t, uint(1), alien.ID)
 	require.Equal(t, city, alien.CurrentCity)
 	require.Equal(t, city.Alien, alien)
 }
@@ -20,7 +20,7 @@ func TestAlienMove(t *testing.T) {
 	borderCity := NewCity("BorderCity")
 	city.borderCities[North] = borderCity
 
-	alien := NewAlien(city)
+	alien := NewAlien(1, city)
 	_, ok := alien.Move(North)
 	require.True(t, ok)
 
@@ -32,8 +32,8 @@ func TestAlienRandomMove(t *testing.T) {
 	borderCity := NewCity("BorderCity")
 	city.borderCities[North] = borderCity
 
-	alien := NewAlien(city)
-	_, ok := alien.RandomMove()
+	alien := NewAlien(1, city)
+	_, ok := alien.RandomMove(NewRand(1))
 	require.True(t, ok)
 
 	require.Equal(t, borderCity, alien.CurrentCity)
@@ -1,6 +1,7 @@
 package invader
 
 import (
+	"bytes"
 	"strings"
 	"testing"
 
@@ -123,7 +124,8 @@ func TestParseInput(t *testing.T) {
 func TestGenerateRandomCity(t *testing.T) {
 	cities := NewCities()
 	depth := 10
-	cities.GenerateRandomCity(depth)
+	err := cities.GenerateRandomCity(depth, NewRand(1))
+	require.NoError(t, err)
 
 	// Check if the number of generated cities is less than or equal to depth * depth
 	require.Less(t, len(cities), depth*depth)
@@ -140,4 +142,33 @@ func TestGenerateRandomCity(t *testing.T) {
 
 		require.True(t, hasBorder)
 	}
+
+	err = NewCities().GenerateRandomCity(0, NewRand(1))
+	require.Error(t, err)
+}
+
+func TestPrint(t *testing.T) {
+	input := `city_10 west=city_9
+city_2 south=city_1 east=city_9
+city_9 west=city_2 east=city_10
+city_1 north=city_2
+trapped
+`
+	want := `city_1 north=city_2
+city_2 east=city_9 south=city_1
+city_9 east=city_10 west=city_2
+city_10 west=city_9
+trapped
+`
+
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader(input))
+	require.NoError(t, err)
+
+	// The output must always be the same
+	for i := 0; i < 10; i++ {
+		var buf bytes.Buffer
+		cities.Print(&buf)
+		require.Equal(t, want, buf.String())
+	}
 }
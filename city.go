@@ -10,6 +10,10 @@ type City struct {
 	Name  string
 	Alien *Alien
 
+	// Attributes holds optional metadata about the city. They are only
+	// preserved by the JSON representation of the map.
+	Attributes map[string]string
+
 	// borderCities maps each direction to a neighbouring city.
 	borderCities map[Direction]*City
 }
@@ -56,11 +60,12 @@ func (c *City) SetDirection(dir Direction, city *City) {
 	c.borderCities[dir] = city
 }
 
-// GetAvailableDirections returns a slice of directions that have cities.
+// GetAvailableDirections returns a slice of directions that have cities, in
+// the order of AllDirections.
 func (c *City) GetAvailableDirections() (dirs []Direction) {
 	dirs = make([]Direction, 0, len(AllDirections))
-	for dir, c := range c.borderCities {
-		if c != nil {
+	for _, dir := range AllDirections {
+		if c := c.borderCities[dir]; c != nil {
 			dirs = append(dirs, dir)
 		}
 	}
@@ -76,14 +81,16 @@ func (c *City) IterateBorder(call func(dir Direction, c *City)) {
 	}
 }
 
-// Print prints the city name and all its neighbouring cities to the writer.
+// Print prints the city name and all its neighbouring cities, in the order of
+// AllDirections, to the writer.
+// Names are quoted when needed, so the line can be read back by Cities.Parse.
 func (c *City) Print(w io.Writer) {
-	dirs := c.GetAvailableDirections()
-	citydirs := make([]string, len(dirs))
-	for i, dir := range dirs {
-		citydir, _ := c.GetDirection(dir)
-		citydirs[i] = fmt.Sprintf("%s=%s", dir, citydir.Name)
-	}
+	var line strings.Builder
+	line.WriteString(quoteName(c.Name))
+	c.IterateBorder(func(dir Direction, citydir *City) {
+		fmt.Fprintf(&line, " %s=%s", dir, quoteName(citydir.Name))
+	})
+	line.WriteByte('\n')
 
-	fmt.Fprintf(w, "%s %s\n", c.Name, strings.Join(citydirs, " "))
+	io.WriteString(w, line.String())
 }
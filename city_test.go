@@ -13,3 +13,15 @@ func TestNewCity(t *testing.T) {
 	require.Equal(t, city.Name, cityName)
 	require.Len(t, city.borderCities, 0)
 }
+
+func TestGetAvailableDirections(t *testing.T) {
+	city := NewCity("TestCity")
+	for _, dir := range []Direction{South, West, North} {
+		city.SetDirection(dir, NewCity(string(dir)))
+	}
+
+	// Directions are always returned in the order of AllDirections
+	for i := 0; i < 10; i++ {
+		require.Equal(t, []Direction{North, West, South}, city.GetAvailableDirections())
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	ffcli "github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type CriticalConfig struct {
+	*RootConfig
+
+	File   string
+	Format string
+	Top    int
+	JSON   bool
+}
+
+// CriticalCommand reports the cities and borders whose destruction splits a
+// map.
+func CriticalCommand(ctx context.Context, logger *log.Logger, cfg *CriticalConfig) error {
+	cities, _, err := loadCities(logger, cfg.File, cfg.Format)
+	if err != nil {
+		return err
+	}
+
+	report := cities.Critical()
+	if cfg.JSON {
+		return report.EncodeJSON(os.Stdout, cfg.Top)
+	}
+
+	report.Print(os.Stdout, cfg.Top)
+	return nil
+}
+
+func criticalCommand(ctx context.Context, logger *log.Logger, rcfg *RootConfig, args []string) *ffcli.Command {
+	var cfg CriticalConfig
+	cfg.RootConfig = rcfg
+
+	flagSet := flag.NewFlagSet("critical", flag.ExitOnError)
+	flagSet.StringVar(&cfg.File, "file", "", "Read from a specified file instead of the standard input.")
+	flagSet.StringVar(&cfg.Format, "format", formatText, "The format of the map, either text or json.")
+	flagSet.IntVar(&cfg.Top, "top", 10, "The number of critical cities to list, every city if not positive.")
+	flagSet.BoolVar(&cfg.JSON, "json", false, "Write the report as JSON.")
+
+	return &ffcli.Command{
+		Name:       "critical",
+		ShortUsage: "invader critical -file [path] -format [text|json] -top [n] -json",
+		ShortHelp:  "Find the cities and borders whose destruction splits a map.",
+		LongHelp: `This subcommand finds the articulation points and the bridges of a
+map, i.e. the cities and borders whose destruction splits the map,
+and ranks the cities by the number of pairs of cities that would no
+longer be connected once they are destroyed.`,
+		FlagSet:     flagSet,
+		Subcommands: []*ffcli.Command{},
+		Exec: func(ctx context.Context, args []string) error {
+			return CriticalCommand(ctx, logger, &cfg)
+		},
+	}
+}
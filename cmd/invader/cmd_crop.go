@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/gfanton/invader"
+	ffcli "github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type CropConfig struct {
+	*RootConfig
+
+	File   string
+	Format string
+	Center string
+	Radius int
+	Output string
+}
+
+// CropCommand extracts the neighbourhood of a city and prints it as a new map.
+func CropCommand(ctx context.Context, logger *log.Logger, cfg *CropConfig) error {
+	if cfg.Center == "" {
+		return fmt.Errorf("no center city given, use -center")
+	}
+
+	// Names are normalized when reading the map, the given one must match them
+	cfg.Center = invader.NormalizeName(cfg.Center)
+
+	if err := checkFormat(cfg.Output, formatText, formatJSON, formatDOT); err != nil {
+		return err
+	}
+
+	cities, header, err := loadCities(logger, cfg.File, cfg.Format)
+	if err != nil {
+		return err
+	}
+
+	cropped, err := cities.Crop(cfg.Center, cfg.Radius)
+	if err != nil {
+		return err
+	}
+
+	logger.Printf("kept %d out of %d cities", len(cropped), len(cities))
+	return writeCities(os.Stdout, cropped, header.Cropped(len(cropped)), cfg.Output)
+}
+
+func cropCommand(ctx context.Context, logger *log.Logger, rcfg *RootConfig, args []string) *ffcli.Command {
+	var cfg CropConfig
+	cfg.RootConfig = rcfg
+
+	flagSet := flag.NewFlagSet("crop", flag.ExitOnError)
+	flagSet.StringVar(&cfg.File, "file", "", "Read from a specified file instead of the standard input.")
+	flagSet.StringVar(&cfg.Format, "format", formatText, "The format of the map, either text or json.")
+	flagSet.StringVar(&cfg.Center, "center", "", "The city to crop the map around.")
+	flagSet.IntVar(&cfg.Radius, "radius", -1, "Keep the cities within this number of hops, the whole connected component if negative.")
+	flagSet.StringVar(&cfg.Output, "output", formatText, "The output format, either text, json or dot.")
+
+	return &ffcli.Command{
+		Name:       "crop",
+		ShortUsage: "invader crop -file [path] -center [city] -radius [n] -output [text|json|dot]",
+		ShortHelp:  "Extract the sub-map around a city.",
+		LongHelp: `This subcommand extracts the cities within -radius hops of the
+-center city, or its whole connected component if the radius is
+negative, and prints them as a new map. Only the borders between two
+kept cities are kept.`,
+		FlagSet:     flagSet,
+		Subcommands: []*ffcli.Command{},
+		Exec: func(ctx context.Context, args []string) error {
+			return CropCommand(ctx, logger, &cfg)
+		},
+	}
+}
@@ -4,9 +4,7 @@ import (
 	"context"
 	"flag"
 	"fmt"
-	"hash/crc32"
 	"log"
-	"math/rand"
 	"os"
 	"time"
 
@@ -17,8 +15,87 @@ import (
 type GenerateConfig struct {
 	*RootConfig
 
-	Depth int
-	Seed  string
+	Depth    int
+	Seed     string
+	Format   string
+	Algo     string
+	Density  float64
+	Loops    int
+	Width    int
+	Height   int
+	Torus    bool
+	Cities   int
+	Stream   bool
+	Names    string
+	Template string
+}
+
+// Map generation algorithms.
+const (
+	algoWalk        = "walk"
+	algoGrid        = "grid"
+	algoMazeDFS     = "maze-dfs"
+	algoMazePrim    = "maze-prim"
+	algoMazeEller   = "maze-eller"
+	algoPercolation = "percolation"
+	algoTree        = "tree"
+	algoGrowth      = "growth"
+)
+
+func newGenerator(cfg *GenerateConfig) (invader.Generator, error) {
+	// Only the maps laid out on a grid can wrap around
+	if cfg.Torus && (cfg.Template != "" || cfg.Algo == algoWalk || cfg.Algo == algoGrowth) {
+		return nil, fmt.Errorf("-torus cannot be used with templates or the %s and %s algorithms", algoWalk, algoGrowth)
+	}
+
+	if cfg.Template != "" {
+		f, err := os.Open(cfg.Template)
+		if err != nil {
+			return nil, fmt.Errorf("unable to open template `%s`: %w", cfg.Template, err)
+		}
+		defer f.Close()
+
+		template, err := invader.ReadTemplate(f)
+		if err != nil {
+			return nil, fmt.Errorf("invalid template `%s`: %w", cfg.Template, err)
+		}
+
+		return template, nil
+	}
+
+	// Grid dimensions default to the depth, growth is only bounded if one of
+	// them is given
+	shape := invader.GridShape{Width: cfg.Width, Height: cfg.Height, Torus: cfg.Torus}
+	if cfg.Algo != algoGrowth || shape.Width != 0 || shape.Height != 0 {
+		if shape.Width == 0 {
+			shape.Width = cfg.Depth
+		}
+		if shape.Height == 0 {
+			shape.Height = cfg.Depth
+		}
+	}
+
+	switch cfg.Algo {
+	case algoWalk:
+		return invader.WalkGenerator{Depth: cfg.Depth}, nil
+	case algoGrid:
+		return invader.GridGenerator{GridShape: shape}, nil
+	case algoMazeDFS:
+		return invader.MazeGenerator{GridShape: shape, Algorithm: invader.MazeDFS}, nil
+	case algoMazePrim:
+		return invader.MazeGenerator{GridShape: shape, Algorithm: invader.MazePrim}, nil
+	case algoMazeEller:
+		return invader.MazeGenerator{GridShape: shape, Algorithm: invader.MazeEller}, nil
+	case algoPercolation:
+		return invader.PercolationGenerator{GridShape: shape, Density: cfg.Density}, nil
+	case algoTree:
+		return invader.TreeGenerator{GridShape: shape, Loops: cfg.Loops}, nil
+	case algoGrowth:
+		return invader.GrowthGenerator{Cities: cfg.Cities, Width: shape.Width, Height: shape.Height, Density: cfg.Density}, nil
+	}
+
+	return nil, fmt.Errorf("unknown generation algorithm `%s`, should be one of %v", cfg.Algo,
+		[]string{algoWalk, algoGrid, algoMazeDFS, algoMazePrim, algoMazeEller, algoPercolation, algoTree, algoGrowth})
 }
 
 // GenerateCommand generates a new random city map and prints it to stdout.
@@ -27,21 +104,102 @@ func GenerateCommand(ctx context.Context, logger *log.Logger, cfg *GenerateConfi
 		return fmt.Errorf("depth cannot be null or negative")
 	}
 
-	logger.Printf("generating new map of size %d", cfg.Depth)
+	if err := checkFormat(cfg.Format, formatText, formatJSON, formatDOT); err != nil {
+		return err
+	}
+
+	generator, err := newGenerator(cfg)
+	if err != nil {
+		return err
+	}
+
+	logger.Printf("generating new map of size %d using %s", cfg.Depth, cfg.Algo)
 
 	if cfg.Seed == "" {
 		cfg.Seed = fmt.Sprintf("%d", time.Now().UnixNano())
 	}
 
-	seed := int64(crc32.ChecksumIEEE([]byte(cfg.Seed)))
 	logger.Printf("using seed %s", cfg.Seed)
+	r := invader.NewRand(invader.SeedFromString(cfg.Seed))
+
+	if cfg.Stream {
+		if _, ok := generator.(invader.StreamGenerator); !ok {
+			return fmt.Errorf("the %s algorithm cannot be streamed", cfg.Algo)
+		}
 
-	// Set seed
-	rand.Seed(seed)
+		// Unique names need to be remembered, which would defeat streaming
+		if cfg.Names != namesDefault {
+			return fmt.Errorf("-names cannot be used with -stream")
+		}
+	}
+
+	if cfg.Names != namesDefault {
+		source, err := newNameSource(cfg.Names)
+		if err != nil {
+			return err
+		}
+
+		// Names are drawn from their own generator, so they don't change the
+		// map structure
+		namer := invader.NewNamer(source, invader.NewRand(invader.SeedFromString("names:"+cfg.Seed)))
+		generator = invader.NamedGenerator{Generator: generator, Namer: namer}
+	}
+
+	if cfg.Stream {
+		return streamCities(generator, r, cfg)
+	}
 
 	cities := invader.NewCities()
-	cities.GenerateRandomCity(cfg.Depth)
-	cities.Print(os.Stdout)
+	if err := generator.Generate(cities, r); err != nil {
+		return fmt.Errorf("unable to generate map: %w", err)
+	}
+
+	return writeCities(os.Stdout, cities, invader.MapHeader{}, cfg.Format)
+}
+
+// Built-in themes of city names.
+const (
+	namesDefault   = "default"
+	namesSyllables = "syllables"
+	namesCapitals  = "capitals"
+)
+
+// newNameSource returns the source of names of the given built-in theme, or
+// reads the word list file at the given path.
+func newNameSource(names string) (invader.NameSource, error) {
+	switch names {
+	case namesSyllables:
+		return invader.Syllables(), nil
+	case namesCapitals:
+		return invader.Capitals(), nil
+	}
+
+	f, err := os.Open(names)
+	if err != nil {
+		return nil, fmt.Errorf("unknown names theme `%s`, should be one of %v or a word list file: %w",
+			names, []string{namesDefault, namesSyllables, namesCapitals}, err)
+	}
+	defer f.Close()
+
+	return invader.ReadWordList(f)
+}
+
+// streamCities writes the map while it is generated, without holding it in
+// memory.
+func streamCities(generator invader.Generator, r *invader.Rand, cfg *GenerateConfig) error {
+	if cfg.Format != formatText {
+		return fmt.Errorf("only the text format can be streamed")
+	}
+
+	streamer, ok := generator.(invader.StreamGenerator)
+	if !ok {
+		return fmt.Errorf("the %s algorithm cannot be streamed", cfg.Algo)
+	}
+
+	if err := streamer.Stream(os.Stdout, r); err != nil {
+		return fmt.Errorf("unable to generate map: %w", err)
+	}
+
 	return nil
 }
 
@@ -52,12 +210,23 @@ func generateCommand(ctx context.Context, logger *log.Logger, rcfg *RootConfig,
 	flagSet := flag.NewFlagSet("generate", flag.ExitOnError)
 	flagSet.StringVar(&cfg.Seed, "seed", "", "the seed used to generate the map; a random seed will be chosen if left empty")
 	flagSet.IntVar(&cfg.Depth, "depth", 5, "the depth of the desired map")
+	flagSet.StringVar(&cfg.Format, "format", formatText, "the output format, either text, json or dot")
+	flagSet.StringVar(&cfg.Algo, "algo", algoWalk, "the generation algorithm, either walk, grid, maze-dfs, maze-prim, maze-eller, percolation, tree or growth")
+	flagSet.Float64Var(&cfg.Density, "density", 0.5, "the probability of each optional border with the percolation and growth algorithms")
+	flagSet.IntVar(&cfg.Loops, "loops", 0, "the number of borders added to the spanning tree with the tree algorithm")
+	flagSet.IntVar(&cfg.Width, "width", 0, "the width of the grid, defaults to the depth")
+	flagSet.IntVar(&cfg.Height, "height", 0, "the height of the grid, defaults to the depth")
+	flagSet.BoolVar(&cfg.Torus, "torus", false, "wrap the grid around, connecting its opposite edges")
+	flagSet.IntVar(&cfg.Cities, "cities", 100, "the exact number of cities with the growth algorithm")
+	flagSet.StringVar(&cfg.Names, "names", namesDefault, "the city names, either default, syllables, capitals or the path of a word list file")
+	flagSet.StringVar(&cfg.Template, "template", "", "build the map drawn in the given template file instead of generating one")
+	flagSet.BoolVar(&cfg.Stream, "stream", false, "write the map while generating it, only with the grid, maze-eller and percolation algorithms")
 
 	return &ffcli.Command{
 		Name:        "generate",
-		ShortUsage:  "invader generate -depth [value] -seed [string]",
+		ShortUsage:  "invader generate -algo [name] -depth [value] -width [value] -height [value] -torus -cities [value] -names [theme] -template [path] -stream -seed [string] -format [text|json|dot]",
 		ShortHelp:   "generate a new random cities with the given depth",
-		LongHelp:    "This subcommand is used to generate a new random city map of a given depth.",
+		LongHelp:    "This subcommand is used to generate a new random city map of a given depth, using one of several algorithms.",
 		FlagSet:     flagSet,
 		Subcommands: []*ffcli.Command{},
 		Exec: func(ctx context.Context, args []string) error {
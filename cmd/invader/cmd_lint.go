@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"regexp"
+
+	"github.com/gfanton/invader"
+	ffcli "github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type LintConfig struct {
+	*RootConfig
+
+	File        string
+	JSON        bool
+	NamePattern string
+}
+
+// LintCommand reports the problems of a map without running any simulation.
+func LintCommand(ctx context.Context, logger *log.Logger, cfg *LintConfig) error {
+	var err error
+
+	lcfg := invader.LintConfig{FileName: "<stdin>"}
+	if cfg.NamePattern != "" {
+		if lcfg.NamePattern, err = regexp.Compile(cfg.NamePattern); err != nil {
+			return fmt.Errorf("invalid name pattern: %w", err)
+		}
+	}
+
+	reader := os.Stdin
+	if cfg.File != "" {
+		if reader, err = os.Open(cfg.File); err != nil {
+			return fmt.Errorf("unable to open file `%s`: %w", cfg.File, err)
+		}
+		defer reader.Close()
+
+		lcfg.FileName = cfg.File
+		logger.Printf("Linting `%s` file map", cfg.File)
+	}
+
+	report, err := invader.Lint(reader, lcfg)
+	if err != nil {
+		return fmt.Errorf("unable to lint the given map: %w", err)
+	}
+
+	if cfg.JSON {
+		if err := report.EncodeJSON(os.Stdout); err != nil {
+			return err
+		}
+	} else {
+		report.Print(os.Stdout)
+	}
+
+	if n := report.Count(invader.SeverityError); n > 0 {
+		return fmt.Errorf("the map has %d error(s)", n)
+	}
+
+	return nil
+}
+
+func lintCommand(ctx context.Context, logger *log.Logger, rcfg *RootConfig, args []string) *ffcli.Command {
+	var cfg LintConfig
+	cfg.RootConfig = rcfg
+
+	flagSet := flag.NewFlagSet("lint", flag.ExitOnError)
+	flagSet.StringVar(&cfg.File, "file", "", "Read from a specified file instead of the standard input.")
+	flagSet.BoolVar(&cfg.JSON, "json", false, "Write the report as JSON.")
+	flagSet.StringVar(&cfg.NamePattern, "names", "", "A regular expression every city name must match.")
+
+	return &ffcli.Command{
+		Name:       "lint",
+		ShortUsage: "invader lint -file [path] -json -names [regexp]",
+		ShortHelp:  "Report the problems of a map without running a simulation.",
+		LongHelp: `This subcommand loads a map and reports its problems: syntax
+errors, conflicting or asymmetric borders, undeclared, isolated or
+disconnected cities, duplicate lines, name convention violations and
+format version issues. It exits with a non-zero status if any error
+has been found.`,
+		FlagSet:     flagSet,
+		Subcommands: []*ffcli.Command{},
+		Exec: func(ctx context.Context, args []string) error {
+			return LintCommand(ctx, logger, &cfg)
+		},
+	}
+}
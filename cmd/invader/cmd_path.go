@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+
+	invader "github.com/gfanton/invader"
+	ffcli "github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type PathConfig struct {
+	*RootConfig
+
+	File   string
+	Format string
+	From   string
+	To     string
+	Hops   int
+	JSON   bool
+}
+
+// PathCommand looks for the shortest path between two cities, or lists the
+// cities reachable from a city if no destination is given.
+func PathCommand(ctx context.Context, logger *log.Logger, cfg *PathConfig) error {
+	if cfg.From == "" {
+		return fmt.Errorf("no starting city given, use -from")
+	}
+
+	// Names are normalized when reading the map, the given ones must match them
+	cfg.From, cfg.To = invader.NormalizeName(cfg.From), invader.NormalizeName(cfg.To)
+
+	cities, _, err := loadCities(logger, cfg.File, cfg.Format)
+	if err != nil {
+		return err
+	}
+
+	if cfg.To == "" {
+		return printReachable(cities, cfg)
+	}
+
+	path, err := cities.ShortestPath(cfg.From, cfg.To)
+	if err != nil {
+		return err
+	}
+
+	if cfg.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(path)
+	}
+
+	fmt.Println(path)
+	fmt.Printf("%d step(s)\n", path.Len())
+	return nil
+}
+
+type reachableCity struct {
+	City     string `json:"city"`
+	Distance int    `json:"distance"`
+}
+
+func printReachable(cities invader.Cities, cfg *PathConfig) error {
+	hops := cfg.Hops
+	if hops < 0 {
+		hops = len(cities)
+	}
+
+	dist, err := cities.WithinHops(cfg.From, hops)
+	if err != nil {
+		return err
+	}
+
+	reachable := make([]reachableCity, 0, len(dist))
+	for city, d := range dist {
+		reachable = append(reachable, reachableCity{City: city.Name, Distance: d})
+	}
+
+	sort.Slice(reachable, func(i, j int) bool {
+		if reachable[i].Distance != reachable[j].Distance {
+			return reachable[i].Distance < reachable[j].Distance
+		}
+
+		return invader.NaturalLess(reachable[i].City, reachable[j].City)
+	})
+
+	if cfg.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(reachable)
+	}
+
+	for _, r := range reachable {
+		fmt.Printf("%d\t%s\n", r.Distance, r.City)
+	}
+
+	return nil
+}
+
+func pathCommand(ctx context.Context, logger *log.Logger, rcfg *RootConfig, args []string) *ffcli.Command {
+	var cfg PathConfig
+	cfg.RootConfig = rcfg
+
+	flagSet := flag.NewFlagSet("path", flag.ExitOnError)
+	flagSet.StringVar(&cfg.File, "file", "", "Read from a specified file instead of the standard input.")
+	flagSet.StringVar(&cfg.Format, "format", formatText, "The format of the map, either text or json.")
+	flagSet.StringVar(&cfg.From, "from", "", "The starting city.")
+	flagSet.StringVar(&cfg.To, "to", "", "The destination city, if empty list the reachable cities instead.")
+	flagSet.IntVar(&cfg.Hops, "hops", -1, "Only list the cities reachable within this number of hops, no limit if negative.")
+	flagSet.BoolVar(&cfg.JSON, "json", false, "Write the result as JSON.")
+
+	return &ffcli.Command{
+		Name:       "path",
+		ShortUsage: "invader path -file [path] -from [city] -to [city] -hops [n] -json",
+		ShortHelp:  "Find the shortest path between two cities.",
+		LongHelp: `This subcommand prints the shortest path between the -from and
+-to cities, as a sequence of directions. Without -to, it lists the
+cities reachable from the -from city along with their distance,
+optionally limited to -hops hops.`,
+		FlagSet:     flagSet,
+		Subcommands: []*ffcli.Command{},
+		Exec: func(ctx context.Context, args []string) error {
+			return PathCommand(ctx, logger, &cfg)
+		},
+	}
+}
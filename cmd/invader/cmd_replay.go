@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/gfanton/invader"
+	ffcli "github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type ReplayConfig struct {
+	*RootConfig
+
+	File   string
+	To     int
+	Step   bool
+	Format string
+}
+
+// ReplayCommand replays a simulation recorded by `invader start -record` and
+// checks that it ends like the recorded one.
+func ReplayCommand(ctx context.Context, logger *log.Logger, cfg *ReplayConfig) error {
+	if err := checkFormat(cfg.Format, formatText, formatJSON); err != nil {
+		return err
+	}
+
+	f, err := os.Open(cfg.File)
+	if err != nil {
+		return fmt.Errorf("unable to open recording `%s`: %w", cfg.File, err)
+	}
+	defer f.Close()
+
+	// With the JSON format, the final map is the only output on stdout
+	progress := io.Writer(os.Stdout)
+	if cfg.Format == formatJSON {
+		progress = os.Stderr
+	}
+
+	replay, err := invader.NewReplay(logger, progress, bufio.NewReader(f))
+	if err != nil {
+		return fmt.Errorf("unable to replay `%s`: %w", cfg.File, err)
+	}
+
+	ai := replay.Simulation()
+	fmt.Fprintf(progress, "* Map fingerprint: %s\n", ai.Fingerprint())
+	fmt.Fprintf(progress, "* Replaying the simulation with %d aliens\n", len(ai.Outcome().Alive))
+
+	if cfg.To >= 0 {
+		if err := replay.Seek(ctx, cfg.To); err != nil {
+			return err
+		}
+
+		if !cfg.Step {
+			printAliens(progress, ai)
+			return writeCities(os.Stdout, ai.Cities(), ai.Header(), cfg.Format)
+		}
+
+		printIteration(progress, ai)
+	}
+
+	if cfg.Step {
+		quit, err := stepReplay(ctx, progress, replay)
+		if err != nil || quit {
+			return err
+		}
+	}
+
+	ended, err := replay.Finish(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(progress, "* Replay matches the recording: the simulation ended after %d iterations (%s)\n", ended.Iteration, ended.Reason)
+
+	// Print the final state of the map.
+	fmt.Fprintf(progress, "* final map:\n")
+	return writeCities(os.Stdout, ai.Cities(), ai.Header(), cfg.Format)
+}
+
+// printIteration prints the current iteration of the simulation, along with
+// its map and the position of its aliens, to w.
+func printIteration(w io.Writer, ai *invader.AlienInvaders) {
+	printAliens(w, ai)
+	ai.Cities().Print(w, invader.PrintHeader(ai.Header()))
+}
+
+// printAliens prints the current iteration of the simulation and the position
+// of its aliens to w.
+func printAliens(w io.Writer, ai *invader.AlienInvaders) {
+	outcome := ai.Outcome()
+
+	fmt.Fprintf(w, "* Iteration %d:\n", ai.Iteration())
+	for _, alien := range outcome.Alive {
+		fmt.Fprintf(w, "alien %s in `%s`\n", alien.Name(), alien.CurrentCity.Name)
+	}
+	for _, alien := range outcome.Trapped {
+		fmt.Fprintf(w, "alien %s trapped in `%s`\n", alien.Name(), alien.CurrentCity.Name)
+	}
+}
+
+// stepReplay replays one iteration each time enter is pressed, until the end
+// of the recording or until the user continues or quits.
+func stepReplay(ctx context.Context, w io.Writer, replay *invader.Replay) (quit bool, err error) {
+	input := bufio.NewScanner(os.Stdin)
+	for !replay.Done() {
+		fmt.Fprintf(w, "[enter] next iteration, [p] print the map, [c] continue, [q] quit: ")
+		if !input.Scan() {
+			return true, input.Err()
+		}
+
+		switch strings.TrimSpace(input.Text()) {
+		case "":
+		case "p":
+			printIteration(w, replay.Simulation())
+			continue
+		case "c":
+			return false, nil
+		case "q":
+			return true, nil
+		default:
+			continue
+		}
+
+		events, err := replay.Step(ctx)
+		if err != nil {
+			return false, err
+		}
+
+		for _, e := range events {
+			switch e := e.(type) {
+			case invader.AlienMoved:
+				fmt.Fprintf(w, "alien %d moved from `%s` to `%s`\n", e.Alien, e.From, e.To)
+			case invader.IterationCompleted:
+				fmt.Fprintf(w, "* Iteration %d: %d aliens left\n", e.Iteration, e.Alive)
+			}
+		}
+	}
+
+	return false, nil
+}
+
+func replayCommand(ctx context.Context, logger *log.Logger, rcfg *RootConfig, args []string) *ffcli.Command {
+	var cfg ReplayConfig
+	cfg.RootConfig = rcfg
+
+	flagSet := flag.NewFlagSet("replay", flag.ExitOnError)
+	flagSet.IntVar(&cfg.To, "to", -1, "Stop at the given iteration and print the state of the simulation.")
+	flagSet.BoolVar(&cfg.Step, "step", false, "Replay one iteration each time enter is pressed, starting from the -to iteration.")
+	flagSet.StringVar(&cfg.Format, "format", formatText, "The format of the final map, either text or json.")
+
+	return &ffcli.Command{
+		Name:       "replay",
+		ShortUsage: "invader replay -to [iteration] -step -format [text|json] <recording>",
+		ShortHelp:  "Replay a recorded simulation and check its outcome.",
+		LongHelp: `This subcommand replays a simulation recorded with
+invader start -record, and fails at the first event differing from
+the recording. The replay can stop at a given iteration or go
+through the simulation one iteration at a time.`,
+		FlagSet:     flagSet,
+		Subcommands: []*ffcli.Command{},
+		Exec: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return fmt.Errorf("expected the path of a single recording, got %d argument(s)", len(args))
+			}
+
+			cfg.File = args[0]
+			return ReplayCommand(ctx, logger, &cfg)
+		},
+	}
+}
@@ -1,11 +1,14 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
+	"time"
 
 	"github.com/gfanton/invader"
 	ffcli "github.com/peterbourgon/ff/v3/ffcli"
@@ -16,65 +19,310 @@ type StartConfig struct {
 
 	StepLimit int
 	NAlien    int
+	NAlienSet bool // NAlien has been set explicitly
 	File      string
+	Strict    bool
+	AllErrors bool
+	Format    string
+	DOTFile   string
+	Seed      string
+	Events    string
+	Record    string
+
+	CheckpointEvery int
+	Checkpoint      string
+	Resume          string
 }
 
-// StartCommand begins the simulation of the alien invasion.
+// StartCommand begins the simulation of the alien invasion, or resumes it
+// from a snapshot.
 func StartCommand(ctx context.Context, logger *log.Logger, cfg *StartConfig) error {
-	var err error
+	if err := checkFormat(cfg.Format, formatText, formatJSON); err != nil {
+		return err
+	}
 
-	reader := os.Stdin
-	if cfg.File != "" {
-		if reader, err = os.Open(cfg.File); err != nil {
-			return fmt.Errorf("unable to open file `%s`: %w", cfg.File, err)
+	switch {
+	case cfg.CheckpointEvery < 0:
+		return fmt.Errorf("the checkpoint interval cannot be negative")
+	case cfg.Resume != "" && cfg.Record != "":
+		return fmt.Errorf("a resumed simulation cannot be recorded")
+	}
+
+	if cfg.Seed == "" {
+		cfg.Seed = fmt.Sprintf("%d", time.Now().UnixNano())
+	}
+
+	logger.Printf("using seed %s", cfg.Seed)
+	opts := []invader.InvaderOption{invader.WithSeed(invader.SeedFromString(cfg.Seed))}
+
+	var events *invader.JSONObserver
+	if cfg.Events != "" {
+		f, err := os.Create(cfg.Events)
+		if err != nil {
+			return fmt.Errorf("unable to create events file `%s`: %w", cfg.Events, err)
 		}
+		defer f.Close()
 
-		logger.Printf("Reading `%s` file map", cfg.File)
+		events = invader.NewJSONObserver(f)
+		opts = append(opts, invader.WithObserver(events))
+	}
+
+	var checkpoints *checkpointer
+	if cfg.CheckpointEvery > 0 {
+		checkpoints = &checkpointer{logger: logger, path: cfg.Checkpoint, every: cfg.CheckpointEvery}
+		opts = append(opts, invader.WithObserver(checkpoints))
 	}
 
-	ai := invader.NewAlienInvaders(logger, os.Stdout)
+	// With the JSON format, the final map is the only output on stdout so it can
+	// be piped to other tools, the progress of the simulation goes to stderr
+	progress := io.Writer(os.Stdout)
+	if cfg.Format == formatJSON {
+		progress = os.Stderr
+	}
 
-	if err = ai.ParseMap(reader); err != nil {
-		return fmt.Errorf("unable parse the given map: %w", err)
+	ai := invader.NewAlienInvaders(logger, progress, opts...)
+	if checkpoints != nil {
+		checkpoints.ai = ai
 	}
 
-	if err := ai.GenerateAliens(cfg.NAlien); err != nil {
-		return fmt.Errorf("unable generate `%d` alien: %w", cfg.NAlien, err)
+	var recorder *invader.JSONObserver
+	var record io.Writer
+	if cfg.Record != "" {
+		f, err := os.Create(cfg.Record)
+		if err != nil {
+			return fmt.Errorf("unable to create record file `%s`: %w", cfg.Record, err)
+		}
+		defer f.Close()
+
+		record = f
+	}
+
+	if cfg.Resume != "" {
+		if err := resumeSimulation(ai, cfg.Resume); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(progress, "* Map fingerprint: %s\n", ai.Fingerprint())
+		fmt.Fprintf(progress, "* Resuming the simulation at iteration %d with %d aliens\n", ai.Iteration(), len(ai.Outcome().Alive))
+	} else {
+		var err error
+		if recorder, err = newSimulation(logger, ai, cfg, record); err != nil {
+			return err
+		}
+
+		fmt.Fprintf(progress, "* Map fingerprint: %s\n", ai.Fingerprint())
+		fmt.Fprintf(progress, "* Seed: %s\n", cfg.Seed)
+		fmt.Fprintf(progress, "* Starting the simulation with %d aliens\n", cfg.NAlien)
 	}
 
 	// Run simulation
-	fmt.Printf("* Starting the simulation with %d aliens\n", cfg.NAlien)
-	err = ai.Run(ctx, cfg.StepLimit)
-	switch err {
+	runErr := ai.Run(ctx, cfg.StepLimit)
+
+	// Write the traces even if the simulation failed, they are needed the most
+	// to understand why
+	if events != nil {
+		if err := events.Flush(); err != nil {
+			return fmt.Errorf("unable to write events to `%s`: %w", cfg.Events, err)
+		}
+
+		logger.Printf("events written to `%s`", cfg.Events)
+	}
+
+	if recorder != nil {
+		if err := recorder.Flush(); err != nil {
+			return fmt.Errorf("unable to write the recording to `%s`: %w", cfg.Record, err)
+		}
+
+		logger.Printf("simulation recorded to `%s`", cfg.Record)
+	}
+
+	switch runErr {
 	case nil: // Reached steps limit
-		fmt.Printf("All aliens are exhausted after performing more than %d steps!\n", cfg.StepLimit)
+		fmt.Fprintf(progress, "All aliens are exhausted after performing more than %d steps!\n", cfg.StepLimit)
 	case invader.ErrAllAliensAreKO:
-		fmt.Printf("All aliens have been killed/trapped!\n")
+		fmt.Fprintf(progress, "All aliens have been killed/trapped!\n")
 	default:
-		return err
+		return runErr
 	}
 
 	logger.Print("Simulation completed!")
 
+	if checkpoints != nil && checkpoints.err != nil {
+		return fmt.Errorf("unable to write checkpoint `%s`: %w", cfg.Checkpoint, checkpoints.err)
+	}
+
+	if cfg.DOTFile != "" {
+		if err := writeDOT(ai, cfg.DOTFile); err != nil {
+			return fmt.Errorf("unable to write graph: %w", err)
+		}
+
+		logger.Printf("graph written to `%s`", cfg.DOTFile)
+	}
+
 	// Print the final state of the map.
-	fmt.Printf("* final map:\n")
-	ai.PrintMap()
+	fmt.Fprintf(progress, "* final map:\n")
+	return writeCities(os.Stdout, ai.Cities(), ai.Header(), cfg.Format)
+}
+
+// newSimulation reads the map and places the aliens. It starts recording the
+// simulation to record if not nil, returning the recorder to flush at the end.
+func newSimulation(logger *log.Logger, ai *invader.AlienInvaders, cfg *StartConfig, record io.Writer) (*invader.JSONObserver, error) {
+	var err error
+
+	reader, filename := os.Stdin, "<stdin>"
+	if cfg.File != "" {
+		filename = cfg.File
+		if reader, err = os.Open(cfg.File); err != nil {
+			return nil, fmt.Errorf("unable to open file `%s`: %w", cfg.File, err)
+		}
+		defer reader.Close()
+
+		logger.Printf("Reading `%s` file map", cfg.File)
+	}
+
+	opts := []invader.ParseOption{invader.WithFileName(filename)}
+	if cfg.Strict {
+		opts = append(opts, invader.WithStrict())
+	}
+	if cfg.AllErrors {
+		opts = append(opts, invader.WithAllErrors())
+	}
+
+	switch cfg.Format {
+	case formatJSON:
+		err = ai.DecodeMap(reader, opts...)
+	default:
+		err = ai.ParseMap(reader, opts...)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("unable parse the given map: %w", err)
+	}
+
+	// Use the map recommendation unless the number of aliens has been given,
+	// without exceeding the number of cities
+	if header := ai.Header(); !cfg.NAlienSet && header.Aliens > 0 {
+		cfg.NAlien = header.Aliens
+		if cities := len(ai.Cities()); cfg.NAlien > cities {
+			logger.Printf("the map recommends %d aliens but only has %d cities", header.Aliens, cities)
+			cfg.NAlien = cities
+		}
+
+		logger.Printf("using %d aliens as recommended by the map", cfg.NAlien)
+	}
+
+	// The recording starts with the map, before placing the aliens
+	var recorder *invader.JSONObserver
+	if record != nil {
+		if recorder, err = ai.Record(record); err != nil {
+			return nil, fmt.Errorf("unable to record the simulation: %w", err)
+		}
+	}
+
+	if err := ai.GenerateAliens(cfg.NAlien); err != nil {
+		return nil, fmt.Errorf("unable generate `%d` alien: %w", cfg.NAlien, err)
+	}
+
+	return recorder, nil
+}
+
+// resumeSimulation restores the simulation saved in the given snapshot.
+func resumeSimulation(ai *invader.AlienInvaders, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("unable to open snapshot `%s`: %w", path, err)
+	}
+	defer f.Close()
+
+	if err := ai.LoadSnapshot(bufio.NewReader(f)); err != nil {
+		return fmt.Errorf("unable to resume `%s`: %w", path, err)
+	}
 
 	return nil
 }
 
+// checkpointer saves a snapshot of the simulation every few iterations.
+type checkpointer struct {
+	ai     *invader.AlienInvaders
+	logger *log.Logger
+	path   string
+	every  int
+	err    error // first error, checkpoints are skipped afterward
+}
+
+func (c *checkpointer) Observe(e invader.Event) {
+	completed, ok := e.(invader.IterationCompleted)
+	if !ok || completed.Iteration%c.every != 0 || c.err != nil {
+		return
+	}
+
+	if c.err = writeSnapshot(c.ai, c.path); c.err == nil {
+		c.logger.Printf("iteration[%d]: checkpoint written to `%s`", completed.Iteration, c.path)
+	}
+}
+
+// writeSnapshot writes the snapshot to a temporary file first, so an
+// interruption never leaves a truncated snapshot behind.
+func writeSnapshot(ai *invader.AlienInvaders, path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	if err := ai.SaveSnapshot(w); err != nil {
+		return err
+	}
+
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp, path)
+}
+
+func writeDOT(ai *invader.AlienInvaders, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if err := ai.WriteDOT(f); err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
 func startCommand(ctx context.Context, logger *log.Logger, rcfg *RootConfig, args []string) *ffcli.Command {
 	var cfg StartConfig
 	cfg.RootConfig = rcfg
 
 	flagSet := flag.NewFlagSet("start", flag.ExitOnError)
-	flagSet.IntVar(&cfg.NAlien, "aliens", 4, "The number of aliens that will be generated on the map")
+	flagSet.IntVar(&cfg.NAlien, "aliens", 4, "The number of aliens that will be generated on the map, defaults to the map recommendation if any")
 	flagSet.IntVar(&cfg.StepLimit, "max_steps", 10000, "The maximum number of steps an alien can perform before becoming exhausted.")
 	flagSet.StringVar(&cfg.File, "file", "", "Read from a specified file instead of the standard input.")
+	flagSet.BoolVar(&cfg.Strict, "strict", false, "Report every contradictory or one-sided border instead of overwriting them.")
+	flagSet.StringVar(&cfg.Format, "format", formatText, "The format of the map, either text or json.")
+	flagSet.StringVar(&cfg.DOTFile, "dot", "", "Write the final map, highlighting the simulation outcome, as a GraphViz DOT graph to the given file.")
+	flagSet.BoolVar(&cfg.AllErrors, "all-errors", false, "Keep parsing after an invalid line and report every error at once.")
+	flagSet.StringVar(&cfg.Events, "events", "", "Write every event of the simulation as JSON Lines to the given file.")
+	flagSet.StringVar(&cfg.Record, "record", "", "Record the simulation to the given file, to be replayed by the replay subcommand.")
+	flagSet.IntVar(&cfg.CheckpointEvery, "checkpoint-every", 0, "Save a snapshot of the simulation every given number of iterations, never if 0.")
+	flagSet.StringVar(&cfg.Checkpoint, "checkpoint", "invader.snapshot", "The file the snapshots of the simulation are saved to.")
+	flagSet.StringVar(&cfg.Resume, "resume", "", "Resume the simulation saved in the given snapshot instead of reading a map.")
+	flagSet.StringVar(&cfg.Seed, "seed", "", "The seed of the simulation, a random seed will be chosen if left empty.")
 
 	return &ffcli.Command{
 		Name:       "start",
-		ShortUsage: "invader start -alien [value] -file [path] -max_steps [value]",
+		ShortUsage: "invader start -alien [value] -file [path] -max_steps [value] -format [text|json] -dot [path] -events [path] -record [path] -checkpoint-every [n] -checkpoint [path] -resume [path] -seed [string] -strict -all-errors",
 		ShortHelp:  "Start the invader simulation by reading from the standard input.",
 		LongHelp: `This subcommand initiates the Alien Invaders simulation. The
 program reads from standard input by default, but you can
@@ -82,6 +330,10 @@ specify a file insteaad.`,
 		FlagSet:     flagSet,
 		Subcommands: []*ffcli.Command{},
 		Exec: func(ctx context.Context, args []string) error {
+			flagSet.Visit(func(f *flag.Flag) {
+				cfg.NAlienSet = cfg.NAlienSet || f.Name == "aliens"
+			})
+
 			return StartCommand(ctx, logger, &cfg)
 		},
 	}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"log"
+	"os"
+
+	ffcli "github.com/peterbourgon/ff/v3/ffcli"
+)
+
+type StatsConfig struct {
+	*RootConfig
+
+	File   string
+	Format string
+	JSON   bool
+}
+
+// StatsCommand reports the structure of a map.
+func StatsCommand(ctx context.Context, logger *log.Logger, cfg *StatsConfig) error {
+	cities, _, err := loadCities(logger, cfg.File, cfg.Format)
+	if err != nil {
+		return err
+	}
+
+	stats := cities.Stats()
+	if cfg.JSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(&stats)
+	}
+
+	stats.Print(os.Stdout)
+	return nil
+}
+
+func statsCommand(ctx context.Context, logger *log.Logger, rcfg *RootConfig, args []string) *ffcli.Command {
+	var cfg StatsConfig
+	cfg.RootConfig = rcfg
+
+	flagSet := flag.NewFlagSet("stats", flag.ExitOnError)
+	flagSet.StringVar(&cfg.File, "file", "", "Read from a specified file instead of the standard input.")
+	flagSet.StringVar(&cfg.Format, "format", formatText, "The format of the map, either text or json.")
+	flagSet.BoolVar(&cfg.JSON, "json", false, "Write the statistics as JSON.")
+
+	return &ffcli.Command{
+		Name:       "stats",
+		ShortUsage: "invader stats -file [path] -format [text|json] -json",
+		ShortHelp:  "Report the structure of a map.",
+		LongHelp: `This subcommand reports the number of cities and borders, the
+degree distribution, the dead ends, the connected components, the
+diameter and the average shortest path length of a map.`,
+		FlagSet:     flagSet,
+		Subcommands: []*ffcli.Command{},
+		Exec: func(ctx context.Context, args []string) error {
+			return StatsCommand(ctx, logger, &cfg)
+		},
+	}
+}
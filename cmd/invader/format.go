@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/gfanton/invader"
+)
+
+// Map formats supported by the commands.
+const (
+	formatText = "text"
+	formatJSON = "json"
+	formatDOT  = "dot" // output only
+)
+
+func checkFormat(format string, supported ...string) error {
+	for _, f := range supported {
+		if f == format {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("unknown map format `%s`, should be one of %v", format, supported)
+}
+
+// loadCities reads a map of the given format from the file, or from the
+// standard input if the file is empty.
+func loadCities(logger *log.Logger, file, format string, opts ...invader.ParseOption) (invader.Cities, invader.MapHeader, error) {
+	var header invader.MapHeader
+
+	if err := checkFormat(format, formatText, formatJSON); err != nil {
+		return nil, header, err
+	}
+
+	reader, filename := io.Reader(os.Stdin), "<stdin>"
+	if file != "" {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, header, fmt.Errorf("unable to open file `%s`: %w", file, err)
+		}
+		defer f.Close()
+
+		reader, filename = f, file
+		logger.Printf("Reading `%s` file map", file)
+	}
+
+	opts = append([]invader.ParseOption{invader.WithFileName(filename), invader.WithHeader(&header)}, opts...)
+
+	var err error
+	cities := invader.NewCities()
+	switch format {
+	case formatJSON:
+		err = cities.DecodeJSON(reader, opts...)
+	default:
+		err = cities.Parse(reader, opts...)
+	}
+
+	if err != nil {
+		return nil, header, fmt.Errorf("unable parse the given map: %w", err)
+	}
+
+	logger.Printf("successfully parsed %d cities", len(cities))
+	return cities, header, nil
+}
+
+// writeCities writes the map in the given format, along with its header.
+// Cities are pinned to their grid position in the DOT format.
+func writeCities(w io.Writer, cities invader.Cities, header invader.MapHeader, format string) error {
+	switch format {
+	case formatJSON:
+		return cities.EncodeJSON(w, invader.PrintHeader(header))
+	case formatDOT:
+		return cities.WriteDOT(w, invader.WithLayout(cities.Layout()))
+	}
+
+	cities.Print(w, invader.PrintHeader(header))
+	return nil
+}
@@ -59,6 +59,12 @@ func main() {
 		Subcommands: []*ffcli.Command{
 			startCommand(ctx, logger, rcfg, args),
 			generateCommand(ctx, logger, rcfg, args),
+			lintCommand(ctx, logger, rcfg, args),
+			statsCommand(ctx, logger, rcfg, args),
+			pathCommand(ctx, logger, rcfg, args),
+			criticalCommand(ctx, logger, rcfg, args),
+			cropCommand(ctx, logger, rcfg, args),
+			replayCommand(ctx, logger, rcfg, args),
 		},
 	}
 
@@ -0,0 +1,243 @@
+package invader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BorderDeclaration is a single `direction=city` entry read from a map line.
+type BorderDeclaration struct {
+	Line      int // line number of the declaration, starting at 1
+	City      string
+	Direction Direction
+	Neighbor  string
+}
+
+func (b BorderDeclaration) String() string {
+	return fmt.Sprintf("`%s` %s=`%s`", b.City, b.Direction, b.Neighbor)
+}
+
+// ConflictKind describes why a border declaration has been rejected.
+type ConflictKind int
+
+const (
+	// ConflictOverwrite means the declaration would replace a border already set
+	// for the same city and direction.
+	ConflictOverwrite ConflictKind = iota
+	// ConflictReverse means the reverse of the declaration is already set to
+	// another city.
+	ConflictReverse
+	// ConflictSameNeighbor means the city is already bordered by the same
+	// neighbour in another direction.
+	ConflictSameNeighbor
+	// ConflictOneSided means the neighbour has its own line but doesn't declare
+	// the border back.
+	ConflictOneSided
+)
+
+func (k ConflictKind) String() string {
+	switch k {
+	case ConflictOverwrite:
+		return "overwrite"
+	case ConflictReverse:
+		return "reverse"
+	case ConflictSameNeighbor:
+		return "same-neighbor"
+	case ConflictOneSided:
+		return "one-sided"
+	}
+
+	return fmt.Sprintf("ConflictKind(%d)", int(k))
+}
+
+// BorderConflict reports a declaration that disagrees with a previous one.
+type BorderConflict struct {
+	Kind ConflictKind
+
+	// Border is the rejected declaration.
+	Border BorderDeclaration
+	// Previous is the declaration it conflicts with. For one-sided borders,
+	// only its Line and City are set and point to the neighbour's own line.
+	Previous BorderDeclaration
+}
+
+func (c BorderConflict) String() string {
+	return fmt.Sprintf("line %d: %s", c.Border.Line, c.Message())
+}
+
+// Message describes the conflict, without its line.
+func (c BorderConflict) Message() string {
+	switch c.Kind {
+	case ConflictOverwrite:
+		return fmt.Sprintf("%s overwrites %s declared on line %d", c.Border, c.Previous, c.Previous.Line)
+	case ConflictReverse:
+		return fmt.Sprintf("%s contradicts %s declared on line %d", c.Border, c.Previous, c.Previous.Line)
+	case ConflictSameNeighbor:
+		return fmt.Sprintf("%s borders the same city as %s declared on line %d", c.Border, c.Previous, c.Previous.Line)
+	case ConflictOneSided:
+		return fmt.Sprintf("%s is not declared back by `%s` on line %d", c.Border, c.Previous.City, c.Previous.Line)
+	}
+
+	return fmt.Sprintf("%s conflicts with %s", c.Border, c.Previous)
+}
+
+// ConflictError is returned by a strict Parse when some borders disagree.
+type ConflictError struct {
+	Conflicts []BorderConflict
+}
+
+func (e *ConflictError) Error() string {
+	lines := make([]string, len(e.Conflicts)+1)
+	lines[0] = fmt.Sprintf("%d border conflict(s) found:", len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		lines[i+1] = "  " + c.String()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// borderSlot identifies the border of a city in a given direction.
+type borderSlot struct {
+	city string
+	dir  Direction
+}
+
+// slotValue holds the neighbour set in a slot and the declaration which set it,
+// either explicitly or as its reverse.
+type slotValue struct {
+	neighbor string
+	decl     BorderDeclaration
+}
+
+// borderValidator collects border declarations without touching the graph and
+// reports every declaration that disagrees with the previous ones.
+type borderValidator struct {
+	names     []string // cities in order of appearance
+	seen      map[string]struct{}
+	heads     map[string]int // line on which a city has its own line
+	slots     map[borderSlot]slotValue
+	explicits map[borderSlot]BorderDeclaration
+	accepted  []BorderDeclaration // explicit declarations, without duplicates
+	conflicts []BorderConflict
+}
+
+func newBorderValidator() *borderValidator {
+	return &borderValidator{
+		seen:      make(map[string]struct{}),
+		heads:     make(map[string]int),
+		slots:     make(map[borderSlot]slotValue),
+		explicits: make(map[borderSlot]BorderDeclaration),
+	}
+}
+
+func (v *borderValidator) addName(name string) {
+	if _, ok := v.seen[name]; !ok {
+		v.seen[name] = struct{}{}
+		v.names = append(v.names, name)
+	}
+}
+
+// add registers a city line and its borders.
+func (v *borderValidator) add(line int, cityName string, borders []BorderDeclaration) {
+	v.addName(cityName)
+	if _, ok := v.heads[cityName]; !ok {
+		v.heads[cityName] = line
+	}
+
+	for _, border := range borders {
+		v.addName(border.Neighbor)
+		if conflict, ok := v.check(border); ok {
+			v.conflicts = append(v.conflicts, conflict)
+			continue
+		}
+
+		fwd := borderSlot{border.City, border.Direction}
+		if _, ok := v.explicits[fwd]; !ok {
+			v.explicits[fwd] = border
+			v.accepted = append(v.accepted, border)
+		}
+
+		if _, ok := v.slots[fwd]; !ok {
+			rev := borderSlot{border.Neighbor, border.Direction.Opposite()}
+			v.slots[fwd] = slotValue{neighbor: border.Neighbor, decl: border}
+			v.slots[rev] = slotValue{neighbor: border.City, decl: border}
+		}
+	}
+}
+
+// check looks for a conflict between the given border and the previously
+// accepted ones.
+func (v *borderValidator) check(border BorderDeclaration) (BorderConflict, bool) {
+	fwd := borderSlot{border.City, border.Direction}
+	if prev, ok := v.slots[fwd]; ok && prev.neighbor != border.Neighbor {
+		return BorderConflict{Kind: ConflictOverwrite, Border: border, Previous: prev.decl}, true
+	}
+
+	rev := borderSlot{border.Neighbor, border.Direction.Opposite()}
+	if prev, ok := v.slots[rev]; ok && prev.neighbor != border.City {
+		return BorderConflict{Kind: ConflictReverse, Border: border, Previous: prev.decl}, true
+	}
+
+	for _, dir := range AllDirections {
+		if dir == border.Direction {
+			continue
+		}
+
+		if prev, ok := v.slots[borderSlot{border.City, dir}]; ok && prev.neighbor == border.Neighbor {
+			return BorderConflict{Kind: ConflictSameNeighbor, Border: border, Previous: prev.decl}, true
+		}
+	}
+
+	return BorderConflict{}, false
+}
+
+// apply reports the collected conflicts, or populates the given cities if
+// there are none.
+func (v *borderValidator) apply(cs Cities) error {
+	if conflicts := v.finish(); len(conflicts) > 0 {
+		return &ConflictError{Conflicts: conflicts}
+	}
+
+	v.populate(cs)
+	return nil
+}
+
+// finish completes and returns the conflicts found so far, sorted by line.
+func (v *borderValidator) finish() []BorderConflict {
+	// A border must be declared back by its neighbour if the latter has its own line
+	for _, border := range v.accepted {
+		line, ok := v.heads[border.Neighbor]
+		if !ok {
+			continue
+		}
+
+		rev := borderSlot{border.Neighbor, border.Direction.Opposite()}
+		if _, ok := v.explicits[rev]; !ok {
+			v.conflicts = append(v.conflicts, BorderConflict{
+				Kind:     ConflictOneSided,
+				Border:   border,
+				Previous: BorderDeclaration{Line: line, City: border.Neighbor},
+			})
+		}
+	}
+
+	sort.SliceStable(v.conflicts, func(i, j int) bool {
+		return v.conflicts[i].Border.Line < v.conflicts[j].Border.Line
+	})
+
+	return v.conflicts
+}
+
+// populate adds every city and the accepted borders to the given cities,
+// leaving out the conflicting declarations.
+func (v *borderValidator) populate(cs Cities) {
+	for _, name := range v.names {
+		cs.GetOrCreate(name)
+	}
+
+	for _, border := range v.accepted {
+		city, neighbor := cs.GetOrCreate(border.City), cs.GetOrCreate(border.Neighbor)
+		city.SetDirection(border.Direction, neighbor)
+	}
+}
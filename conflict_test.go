@@ -0,0 +1,99 @@
+package invader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseStrict(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Input     string
+		WantKinds []ConflictKind
+		WantLines []int
+	}{
+		{
+			Name:  "consistent map",
+			Input: "a north=b east=c\nb south=a\nc west=a\n",
+		},
+		{
+			Name:  "duplicated lines",
+			Input: "a north=b\na north=b\nb south=a\n",
+		},
+		{
+			Name:  "undeclared neighbour",
+			Input: "a north=b east=c\n",
+		},
+		{
+			Name:      "overwritten border",
+			Input:     "a north=b\na north=c\n",
+			WantKinds: []ConflictKind{ConflictOverwrite},
+			WantLines: []int{2},
+		},
+		{
+			Name:      "contradictory reverse",
+			Input:     "a north=b\nb south=c\n",
+			WantKinds: []ConflictKind{ConflictOneSided, ConflictOverwrite},
+			WantLines: []int{1, 2},
+		},
+		{
+			Name:      "reverse already taken",
+			Input:     "a north=c\nb north=c\n",
+			WantKinds: []ConflictKind{ConflictReverse},
+			WantLines: []int{2},
+		},
+		{
+			Name:      "same neighbour twice",
+			Input:     "a north=b east=b\n",
+			WantKinds: []ConflictKind{ConflictSameNeighbor},
+			WantLines: []int{1},
+		},
+		{
+			Name:      "one-sided border",
+			Input:     "a north=b\nb\n",
+			WantKinds: []ConflictKind{ConflictOneSided},
+			WantLines: []int{1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cities := NewCities()
+			err := cities.Parse(strings.NewReader(tc.Input), WithStrict())
+			if len(tc.WantKinds) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			var cerr *ConflictError
+			require.True(t, errors.As(err, &cerr))
+			require.Len(t, cerr.Conflicts, len(tc.WantKinds))
+			for i, conflict := range cerr.Conflicts {
+				require.Equal(t, tc.WantKinds[i], conflict.Kind)
+				require.Equal(t, tc.WantLines[i], conflict.Border.Line)
+			}
+
+			// The graph must be left untouched
+			require.Len(t, cities, 0)
+		})
+	}
+}
+
+func TestParseStrictPopulate(t *testing.T) {
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a north=b\nb south=a\nc\n"), WithStrict())
+	require.NoError(t, err)
+	require.Len(t, cities, 3)
+
+	a, _ := cities.Get("a")
+	b, ok := a.GetDirection(North)
+	require.True(t, ok)
+	require.Equal(t, "b", b.Name)
+
+	back, ok := b.GetDirection(South)
+	require.True(t, ok)
+	require.Equal(t, a, back)
+}
@@ -0,0 +1,282 @@
+package invader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Bridge is a border whose destruction splits the map, seen from its northern
+// or western city like in IterateBorders.
+type Bridge struct {
+	City      *City
+	Direction Direction
+	Neighbor  *City
+}
+
+func (b Bridge) String() string {
+	return fmt.Sprintf("`%s` %s=`%s`", b.City.Name, b.Direction, b.Neighbor.Name)
+}
+
+// CriticalCity tells how much the map suffers from the destruction of a city.
+type CriticalCity struct {
+	City *City
+	// Pieces is the number of parts the component of the city splits into
+	// when the city is destroyed: 1 for most cities, more than 1 for an
+	// articulation point and 0 for an isolated city.
+	Pieces int
+	// PairsLost is the number of pairs of cities that are no longer connected
+	// once the city is destroyed, including the pairs involving the city
+	// itself.
+	PairsLost int
+}
+
+// IsArticulation reports whether destroying the city splits its component.
+func (c CriticalCity) IsArticulation() bool {
+	return c.Pieces > 1
+}
+
+// CriticalReport holds the result of the critical analysis of a map.
+type CriticalReport struct {
+	// Cities holds every city, the most critical first: by pairs lost, then
+	// by pieces, then by name.
+	Cities []CriticalCity
+	// Bridges holds the bridges in the order of IterateBorders.
+	Bridges []Bridge
+}
+
+// ArticulationPoints returns the cities whose destruction splits the map,
+// sorted like GetAll.
+func (cs Cities) ArticulationPoints() []*City {
+	points := []*City{}
+	for _, c := range cs.Critical().Cities {
+		if c.IsArticulation() {
+			points = append(points, c.City)
+		}
+	}
+
+	sort.Slice(points, func(i, j int) bool {
+		return NaturalLess(points[i].Name, points[j].Name)
+	})
+
+	return points
+}
+
+// Bridges returns the borders whose destruction splits the map.
+func (cs Cities) Bridges() []Bridge {
+	return cs.Critical().Bridges
+}
+
+// arc is a border seen from one of its cities, in an undirected graph.
+type arc struct {
+	to     int
+	border int
+}
+
+// Critical finds the articulation points and the bridges of the map and ranks
+// every city by the connectivity lost if it is destroyed. Borders are
+// considered in both directions. It runs in linear time using an iterative
+// version of Tarjan's algorithm.
+func (cs Cities) Critical() CriticalReport {
+	g := cs.graph()
+	n := len(g.cities)
+
+	var borders []Bridge
+	adj := make([][]arc, n)
+	cs.IterateBorders(func(city *City, dir Direction, neighbor *City) {
+		j, ok := g.index[neighbor]
+		if !ok {
+			return
+		}
+
+		i, id := g.index[city], len(borders)
+		borders = append(borders, Bridge{City: city, Direction: dir, Neighbor: neighbor})
+		adj[i] = append(adj[i], arc{to: j, border: id})
+		adj[j] = append(adj[j], arc{to: i, border: id})
+	})
+
+	// disc is the discovery time of a city, starting at 1, low the earliest
+	// discovery time reachable from its subtree through a single back edge
+	// and size the number of cities in its subtree.
+	disc, low, size := make([]int, n), make([]int, n), make([]int, n)
+	// Parts left once a city is destroyed, apart from the part holding its
+	// DFS parent.
+	pieces, piecesSize, piecesPairs := make([]int, n), make([]int, n), make([]int, n)
+	isBridge := make([]bool, len(borders))
+
+	type frame struct {
+		city, border, next int
+	}
+
+	report := CriticalReport{
+		Cities:  make([]CriticalCity, 0, n),
+		Bridges: []Bridge{},
+	}
+
+	clock := 0
+	var stack []frame
+	var component []int
+	for root := 0; root < n; root++ {
+		if disc[root] != 0 {
+			continue
+		}
+
+		clock++
+		disc[root], low[root], size[root] = clock, clock, 1
+		stack = append(stack[:0], frame{city: root, border: -1})
+		component = append(component[:0], root)
+		for len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			v := top.city
+			if top.next < len(adj[v]) {
+				a := adj[v][top.next]
+				top.next++
+				switch {
+				case a.border == top.border:
+					// Don't go back through the border we came from
+				case disc[a.to] == 0:
+					clock++
+					disc[a.to], low[a.to], size[a.to] = clock, clock, 1
+					stack = append(stack, frame{city: a.to, border: a.border})
+					component = append(component, a.to)
+				case disc[a.to] < low[v]:
+					low[v] = disc[a.to]
+				}
+
+				continue
+			}
+
+			done := *top
+			stack = stack[:len(stack)-1]
+			if len(stack) == 0 {
+				break
+			}
+
+			p := stack[len(stack)-1].city
+			size[p] += size[v]
+			if low[v] < low[p] {
+				low[p] = low[v]
+			}
+
+			if low[v] > disc[p] {
+				isBridge[done.border] = true
+			}
+
+			// The subtree of v is cut from the rest when p is destroyed
+			if low[v] >= disc[p] {
+				pieces[p]++
+				piecesSize[p] += size[v]
+				piecesPairs[p] += pairs(size[v])
+			}
+		}
+
+		total := size[root]
+		for _, v := range component {
+			c := CriticalCity{City: g.cities[v], Pieces: pieces[v]}
+			after := piecesPairs[v]
+			if rest := total - 1 - piecesSize[v]; rest > 0 {
+				c.Pieces++
+				after += pairs(rest)
+			}
+
+			c.PairsLost = pairs(total) - after
+			report.Cities = append(report.Cities, c)
+		}
+	}
+
+	for id, border := range borders {
+		if isBridge[id] {
+			report.Bridges = append(report.Bridges, border)
+		}
+	}
+
+	sort.Slice(report.Cities, func(i, j int) bool {
+		a, b := report.Cities[i], report.Cities[j]
+		if a.PairsLost != b.PairsLost {
+			return a.PairsLost > b.PairsLost
+		}
+
+		if a.Pieces != b.Pieces {
+			return a.Pieces > b.Pieces
+		}
+
+		return NaturalLess(a.City.Name, b.City.Name)
+	})
+
+	return report
+}
+
+// pairs returns the number of pairs of cities in a group of n cities.
+func pairs(n int) int {
+	return n * (n - 1) / 2
+}
+
+// Print writes the report in a human-readable form, listing the bridges and
+// at most top cities, or every city if top is not positive.
+func (r CriticalReport) Print(w io.Writer, top int) {
+	articulations := 0
+	for _, c := range r.Cities {
+		if c.IsArticulation() {
+			articulations++
+		}
+	}
+
+	fmt.Fprintf(w, "articulation points:  %d\n", articulations)
+	fmt.Fprintf(w, "bridges:              %d\n", len(r.Bridges))
+	for _, bridge := range r.Bridges {
+		fmt.Fprintf(w, "  %s\n", bridge)
+	}
+
+	cities := r.Cities
+	if top > 0 && top < len(cities) {
+		cities = cities[:top]
+	}
+
+	fmt.Fprintln(w, "critical cities:")
+	for i, c := range cities {
+		fmt.Fprintf(w, "  %d. %s: %d pair(s) lost, %d piece(s)\n", i+1, c.City.Name, c.PairsLost, c.Pieces)
+	}
+}
+
+// EncodeJSON writes the report as a JSON object, listing at most top cities,
+// or every city if top is not positive.
+func (r CriticalReport) EncodeJSON(w io.Writer, top int) error {
+	type jsonBridge struct {
+		City      string    `json:"city"`
+		Direction Direction `json:"direction"`
+		Neighbor  string    `json:"neighbor"`
+	}
+
+	type jsonCritical struct {
+		City         string `json:"city"`
+		Articulation bool   `json:"articulation"`
+		Pieces       int    `json:"pieces"`
+		PairsLost    int    `json:"pairs_lost"`
+	}
+
+	cities := r.Cities
+	if top > 0 && top < len(cities) {
+		cities = cities[:top]
+	}
+
+	report := struct {
+		Bridges []jsonBridge   `json:"bridges"`
+		Cities  []jsonCritical `json:"cities"`
+	}{
+		Bridges: make([]jsonBridge, len(r.Bridges)),
+		Cities:  make([]jsonCritical, len(cities)),
+	}
+
+	for i, b := range r.Bridges {
+		report.Bridges[i] = jsonBridge{City: b.City.Name, Direction: b.Direction, Neighbor: b.Neighbor.Name}
+	}
+
+	for i, c := range cities {
+		report.Cities[i] = jsonCritical{City: c.City.Name, Articulation: c.IsArticulation(), Pieces: c.Pieces, PairsLost: c.PairsLost}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&report)
+}
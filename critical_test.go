@@ -0,0 +1,70 @@
+package invader
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCritical(t *testing.T) {
+	// a - b - c     f
+	//     |   |
+	//     d - e
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a east=b\nb east=c south=d\nc south=e\nd east=e\nf\n"))
+	require.NoError(t, err)
+
+	report := cities.Critical()
+	require.Len(t, report.Cities, 6)
+	require.Len(t, report.Bridges, 1)
+	require.Equal(t, "`a` east=`b`", report.Bridges[0].String())
+
+	// b splits `a` from the loop: 4 pairs involving b, plus a-c, a-d and a-e
+	first := report.Cities[0]
+	require.Equal(t, "b", first.City.Name)
+	require.True(t, first.IsArticulation())
+	require.Equal(t, 2, first.Pieces)
+	require.Equal(t, 7, first.PairsLost)
+
+	last := report.Cities[len(report.Cities)-1]
+	require.Equal(t, "f", last.City.Name)
+	require.Equal(t, 0, last.Pieces)
+	require.Equal(t, 0, last.PairsLost)
+
+	points := cities.ArticulationPoints()
+	require.Len(t, points, 1)
+	require.Equal(t, "b", points[0].Name)
+}
+
+func TestCriticalMatchesDestroy(t *testing.T) {
+	f, err := os.Open("maps/small.map")
+	require.NoError(t, err)
+	defer f.Close()
+
+	cities := NewCities()
+	err = cities.Parse(f)
+	require.NoError(t, err)
+
+	connectedPairs := func(cs Cities) (n int) {
+		for _, component := range cs.Components() {
+			n += pairs(len(component))
+		}
+		return
+	}
+
+	before := connectedPairs(cities)
+	for _, c := range cities.Critical().Cities {
+		// Destroy the city on a copy of the map
+		var buf strings.Builder
+		cities.Print(&buf)
+		copied := NewCities()
+		err := copied.Parse(strings.NewReader(buf.String()))
+		require.NoError(t, err)
+
+		copied.Destroy(c.City.Name)
+		require.Equal(t, before-connectedPairs(copied), c.PairsLost, "city `%s`", c.City.Name)
+		require.Equal(t, c.Pieces > 1, len(copied.Components()) > 1, "city `%s`", c.City.Name)
+	}
+}
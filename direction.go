@@ -7,6 +7,9 @@ import (
 
 type Direction string
 
+// ErrInvalidDirection is returned when parsing an unknown direction.
+var ErrInvalidDirection = fmt.Errorf("invalid direction")
+
 // Define the four possible directions.
 const (
 	North Direction = "north"
@@ -21,7 +24,7 @@ func ParseDirection(dir string) (Direction, error) {
 	case North, East, West, South:
 		return d, nil
 	default:
-		return "", fmt.Errorf("invalid direction")
+		return "", fmt.Errorf("%w: `%s`", ErrInvalidDirection, dir)
 	}
 }
 
@@ -0,0 +1,159 @@
+package invader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DestroyedCity records a city destroyed during a simulation along with the
+// borders it had when it was destroyed.
+type DestroyedCity struct {
+	Name    string
+	Borders map[Direction]string
+}
+
+// Outcome summarizes the result of a simulation.
+type Outcome struct {
+	Destroyed []DestroyedCity
+	Alive     []*Alien // aliens still able to move
+	Trapped   []*Alien // aliens stuck in a city without any border
+}
+
+// Colors used to highlight the outcome of a simulation.
+const (
+	dotColorDestroyed = "#e74c3c"
+	dotColorAlive     = "#2ecc71"
+	dotColorTrapped   = "#f39c12"
+)
+
+// DOTOption configures the behaviour of Cities.WriteDOT.
+type DOTOption func(cfg *dotConfig)
+
+type dotConfig struct {
+	outcome *Outcome
+	layout  *Layout
+}
+
+// WithOutcome highlights the outcome of a simulation: destroyed cities are
+// drawn in red along with their former borders, cities hosting a surviving
+// alien in green and cities hosting a trapped alien in orange.
+func WithOutcome(outcome Outcome) DOTOption {
+	return func(cfg *dotConfig) {
+		cfg.outcome = &outcome
+	}
+}
+
+// WithLayout pins every city at its position in the given layout (see
+// Cities.Layout), which is honored by the `neato` and `fdp` engines.
+func WithLayout(layout Layout) DOTOption {
+	return func(cfg *dotConfig) {
+		cfg.layout = &layout
+	}
+}
+
+// dotQuote returns the given string as a DOT quoted identifier.
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
+
+// writeDOTBorder writes a border going from a city toward the given
+// direction, oriented from its northern or western city. Layout hints keep the
+// northern city ranked above the southern one, and western and eastern cities
+// side by side on the same rank.
+func writeDOTBorder(w io.Writer, from, to string, dir Direction, attrs string) {
+	switch dir {
+	case North:
+		from, to, dir = to, from, South
+	case West:
+		from, to, dir = to, from, East
+	}
+
+	if attrs != "" {
+		attrs = ", " + attrs
+	}
+
+	from, to = dotQuote(from), dotQuote(to)
+	switch dir {
+	case South:
+		fmt.Fprintf(w, "  %s -> %s [tailport=s, headport=n%s];\n", from, to, attrs)
+	case East:
+		fmt.Fprintf(w, "  %s -> %s [tailport=e, headport=w, constraint=false%s];\n", from, to, attrs)
+		fmt.Fprintf(w, "  { rank=same; %s; %s; }\n", from, to)
+	}
+}
+
+// WriteDOT writes the cities as a GraphViz DOT graph, which can be rendered
+// with standard tools (e.g. `dot -Tsvg`).
+func (cs Cities) WriteDOT(w io.Writer, opts ...DOTOption) error {
+	var cfg dotConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintln(bw, "digraph invader {")
+	fmt.Fprintln(bw, "  graph [newrank=true];")
+	fmt.Fprintln(bw, "  node [shape=box, style=filled, fillcolor=white];")
+	fmt.Fprintln(bw, "  edge [dir=none];")
+
+	// Nodes highlighted by the outcome
+	labels := make(map[string]string)
+	if cfg.outcome != nil {
+		for _, alien := range cfg.outcome.Alive {
+			labels[alien.CurrentCity.Name] = fmt.Sprintf("label=%s, fillcolor=%q",
+				dotQuote(fmt.Sprintf("%s\nalien %s", alien.CurrentCity.Name, alien.Name())), dotColorAlive)
+		}
+
+		for _, alien := range cfg.outcome.Trapped {
+			labels[alien.CurrentCity.Name] = fmt.Sprintf("label=%s, fillcolor=%q",
+				dotQuote(fmt.Sprintf("%s\nalien %s (trapped)", alien.CurrentCity.Name, alien.Name())), dotColorTrapped)
+		}
+
+		for _, city := range cfg.outcome.Destroyed {
+			fmt.Fprintf(bw, "  %s [fillcolor=%q, style=\"filled,dashed\"];\n", dotQuote(city.Name), dotColorDestroyed)
+		}
+	}
+
+	for _, city := range cs.GetAll() {
+		var attrs []string
+		if label, ok := labels[city.Name]; ok {
+			attrs = append(attrs, label)
+		}
+
+		if cfg.layout != nil {
+			if p, ok := cfg.layout.Positions[city]; ok {
+				attrs = append(attrs, fmt.Sprintf("pos=\"%d,%d!\"", p.X, p.Y))
+			}
+		}
+
+		if len(attrs) > 0 {
+			fmt.Fprintf(bw, "  %s [%s];\n", dotQuote(city.Name), strings.Join(attrs, ", "))
+			continue
+		}
+
+		fmt.Fprintf(bw, "  %s;\n", dotQuote(city.Name))
+	}
+
+	cs.IterateBorders(func(city *City, dir Direction, neighbor *City) {
+		writeDOTBorder(bw, city.Name, neighbor.Name, dir, "")
+	})
+
+	if cfg.outcome != nil {
+		for _, city := range cfg.outcome.Destroyed {
+			for _, dir := range AllDirections {
+				neighbor, ok := city.Borders[dir]
+				if !ok {
+					continue
+				}
+
+				attrs := fmt.Sprintf("style=dashed, color=%q", dotColorDestroyed)
+				writeDOTBorder(bw, city.Name, neighbor, dir, attrs)
+			}
+		}
+	}
+
+	fmt.Fprintln(bw, "}")
+	return bw.Flush()
+}
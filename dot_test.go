@@ -0,0 +1,68 @@
+package invader
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDOT(t *testing.T) {
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a south=b east=\"New York\"\nb north=a\n\"New York\" west=a\n"))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = cities.WriteDOT(&buf)
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.True(t, strings.HasPrefix(out, "digraph invader {\n"))
+	require.True(t, strings.HasSuffix(out, "}\n"))
+
+	// Each border is written once, oriented from north to south and west to east
+	require.Equal(t, 1, strings.Count(out, `"a" -> "b" [tailport=s, headport=n];`))
+	require.Equal(t, 1, strings.Count(out, `"a" -> "New York" [tailport=e, headport=w, constraint=false];`))
+	require.Contains(t, out, `{ rank=same; "a"; "New York"; }`)
+	require.Equal(t, 2, strings.Count(out, "->"))
+}
+
+func TestWriteDOTOutcome(t *testing.T) {
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a south=b\nc\n"))
+	require.NoError(t, err)
+
+	alive := NewAlien(1, cities["a"])
+	trapped := NewAlien(2, cities["c"])
+	outcome := Outcome{
+		Destroyed: []DestroyedCity{{Name: "d", Borders: map[Direction]string{West: "b"}}},
+		Alive:     []*Alien{alive},
+		Trapped:   []*Alien{trapped},
+	}
+
+	var buf bytes.Buffer
+	err = cities.WriteDOT(&buf, WithOutcome(outcome))
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.Contains(t, out, `"d" [fillcolor="`+dotColorDestroyed+`", style="filled,dashed"];`)
+	require.Contains(t, out, `"b" -> "d" [tailport=e, headport=w, constraint=false, style=dashed, color="`+dotColorDestroyed+`"];`)
+	require.Contains(t, out, `"a" [label="a\nalien `+alive.Name()+`", fillcolor="`+dotColorAlive+`"];`)
+	require.Contains(t, out, `"c" [label="c\nalien `+trapped.Name()+` (trapped)", fillcolor="`+dotColorTrapped+`"];`)
+}
+
+func TestWriteDOTLayout(t *testing.T) {
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a south=b east=c\n"))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	err = cities.WriteDOT(&buf, WithLayout(cities.Layout()))
+	require.NoError(t, err)
+
+	out := buf.String()
+	require.Contains(t, out, `"a" [pos="0,1!"];`)
+	require.Contains(t, out, `"b" [pos="0,0!"];`)
+	require.Contains(t, out, `"c" [pos="1,1!"];`)
+}
@@ -0,0 +1,147 @@
+package invader
+
+import (
+	"fmt"
+	"io"
+	"log"
+)
+
+// Event is something that happened during a simulation, delivered to the
+// observers of the simulation. It is one of AlienPlaced, AlienMoved,
+// FightOccurred, CityDestroyed, AlienTrapped, IterationCompleted or
+// SimulationEnded.
+//
+// Events only hold IDs and names, so they can be kept after the simulation
+// moves on. Iterations are numbered from 1, events occurring before the
+// simulation starts belong to iteration 0.
+type Event interface {
+	isEvent()
+}
+
+// AlienPlaced is emitted when an alien is placed on the map.
+type AlienPlaced struct {
+	Iteration int
+	Alien     uint
+	City      string
+}
+
+// AlienMoved is emitted when an alien moves to a neighbouring city.
+type AlienMoved struct {
+	Iteration int
+	Alien     uint
+	From      string
+	To        string
+}
+
+// FightOccurred is emitted when an alien moves into a city occupied by
+// another alien, killing both of them.
+type FightOccurred struct {
+	Iteration int
+	City      string
+	Attacker  uint
+	Defender  uint
+}
+
+// CityDestroyed is emitted when a city is destroyed by a fight, along with
+// the borders it had.
+type CityDestroyed struct {
+	Iteration int
+	City      string
+	Borders   map[Direction]string
+	Aliens    [2]uint // the attacker, then the defender
+}
+
+// AlienTrapped is emitted when an alien cannot move anymore, its city having
+// no border left.
+type AlienTrapped struct {
+	Iteration int
+	Alien     uint
+	City      string
+}
+
+// IterationCompleted is emitted at the end of every iteration.
+type IterationCompleted struct {
+	Iteration int
+	Alive     int // aliens still able to move
+	Dead      int // aliens killed or trapped during the iteration
+}
+
+// Reasons for a simulation to end.
+const (
+	EndAllAliensKO = "all-aliens-ko" // every alien has been killed or trapped
+	EndStepLimit   = "step-limit"    // the iteration limit has been reached
+	EndCancelled   = "cancelled"     // the context has been cancelled
+)
+
+// SimulationEnded is emitted when Run returns.
+type SimulationEnded struct {
+	Iteration int // the last completed iteration
+	Reason    string
+	Alive     int
+	Trapped   int
+	Destroyed int
+}
+
+func (AlienPlaced) isEvent()        {}
+func (AlienMoved) isEvent()         {}
+func (FightOccurred) isEvent()      {}
+func (CityDestroyed) isEvent()      {}
+func (AlienTrapped) isEvent()       {}
+func (IterationCompleted) isEvent() {}
+func (SimulationEnded) isEvent()    {}
+
+// Observer receives the events of a simulation, as they happen.
+type Observer interface {
+	Observe(e Event)
+}
+
+// ObserverFunc is an adapter allowing the use of an ordinary function as an
+// Observer.
+type ObserverFunc func(e Event)
+
+// Observe calls f(e).
+func (f ObserverFunc) Observe(e Event) {
+	f(e)
+}
+
+// TextObserver writes the destroyed cities and the trapped aliens as English
+// sentences, e.g. "Paris has been destroyed by alien 1 and alien 2!".
+type TextObserver struct {
+	w io.Writer
+}
+
+// NewTextObserver returns a TextObserver writing to w.
+func NewTextObserver(w io.Writer) *TextObserver {
+	return &TextObserver{w: w}
+}
+
+// Observe implements Observer.
+func (o *TextObserver) Observe(e Event) {
+	switch e := e.(type) {
+	case AlienTrapped:
+		fmt.Fprintf(o.w, "alien %s has been trapped in `%s`!\n", alienName(e.Alien), e.City)
+	case CityDestroyed:
+		fmt.Fprintf(o.w, "%s has been destroyed by alien %s and alien %s!\n", e.City, alienName(e.Aliens[0]), alienName(e.Aliens[1]))
+	}
+}
+
+// logObserver logs the progress of the simulation.
+type logObserver struct {
+	logger *log.Logger
+}
+
+func (o logObserver) Observe(e Event) {
+	switch e := e.(type) {
+	case AlienMoved:
+		o.logger.Printf("iteration[%d]: alien `%s` moved from `%s` to `%s`", e.Iteration, alienName(e.Alien), e.From, e.To)
+	case FightOccurred:
+		o.logger.Printf("iteration[%d]: city `%s` already occupied by alien `%s`", e.Iteration, e.City, alienName(e.Defender))
+	case IterationCompleted:
+		if e.Dead > 0 {
+			o.logger.Printf("iteration[%d]: %d aliens have been killed/trapped, %d left", e.Iteration, e.Dead, e.Alive)
+		}
+	case SimulationEnded:
+		o.logger.Printf("simulation ended after %d iterations (%s): %d aliens alive, %d trapped, %d cities destroyed",
+			e.Iteration, e.Reason, e.Alive, e.Trapped, e.Destroyed)
+	}
+}
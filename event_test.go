@@ -0,0 +1,65 @@
+package invader
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserverEvents(t *testing.T) {
+	var events []Event
+	observer := ObserverFunc(func(e Event) {
+		events = append(events, e)
+	})
+
+	var buf bytes.Buffer
+	ai := NewAlienInvaders(testInvaderLogger, &buf, WithSeed(1), WithObserver(observer))
+	err := ai.ParseMap(strings.NewReader("a north=b\nc\n"))
+	require.NoError(t, err)
+
+	err = ai.GenerateAliens(3)
+	require.NoError(t, err)
+
+	err = ai.Run(context.Background(), 100)
+	require.Equal(t, ErrAllAliensAreKO, err)
+	require.Equal(t, 1, ai.Iteration())
+
+	// Alien 1 moves first into the city of alien 2, alien 3 cannot move
+	require.Equal(t, []Event{
+		AlienPlaced{Iteration: 0, Alien: 1, City: "a"},
+		AlienPlaced{Iteration: 0, Alien: 2, City: "b"},
+		AlienPlaced{Iteration: 0, Alien: 3, City: "c"},
+		AlienMoved{Iteration: 1, Alien: 1, From: "a", To: "b"},
+		FightOccurred{Iteration: 1, City: "b", Attacker: 1, Defender: 2},
+		CityDestroyed{Iteration: 1, City: "b", Borders: map[Direction]string{South: "a"}, Aliens: [2]uint{1, 2}},
+		AlienTrapped{Iteration: 1, Alien: 3, City: "c"},
+		IterationCompleted{Iteration: 1, Alive: 0, Dead: 3},
+		SimulationEnded{Iteration: 1, Reason: EndAllAliensKO, Alive: 0, Trapped: 1, Destroyed: 1},
+	}, events)
+
+	// The text output is written by the default observer
+	require.Equal(t, "b has been destroyed by alien 1 and alien 2!\nalien 3 has been trapped in `c`!\n", buf.String())
+}
+
+func TestObserverStepLimit(t *testing.T) {
+	var ended []SimulationEnded
+	observer := ObserverFunc(func(e Event) {
+		if e, ok := e.(SimulationEnded); ok {
+			ended = append(ended, e)
+		}
+	})
+
+	ai := NewAlienInvaders(testInvaderLogger, testInvaderDefaultWriter, WithObserver(observer))
+	err := ai.ParseMap(strings.NewReader("a north=b"))
+	require.NoError(t, err)
+
+	err = ai.GenerateAliens(1)
+	require.NoError(t, err)
+
+	err = ai.Run(context.Background(), 10)
+	require.NoError(t, err)
+	require.Equal(t, []SimulationEnded{{Iteration: 10, Reason: EndStepLimit, Alive: 1}}, ended)
+}
@@ -0,0 +1,162 @@
+package invader
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// EventLogVersion is the version of the schema of the events written by
+// JSONObserver. It is increased whenever a field changes meaning or is
+// removed, adding a field keeps the version.
+const EventLogVersion = 1
+
+// jsonEvent is the JSON representation of an event, written on its own line:
+//
+//	{"version":1,"type":"alien_placed","iteration":0,"alien":1,"city":"Paris"}
+//	{"version":1,"type":"alien_moved","iteration":1,"alien":1,"from":"Paris","to":"Lille"}
+//	{"version":1,"type":"fight","iteration":1,"city":"Lille","aliens":[1,2]}
+//	{"version":1,"type":"city_destroyed","iteration":1,"city":"Lille","aliens":[1,2],"borders":{"south":"Paris"}}
+//	{"version":1,"type":"alien_trapped","iteration":1,"alien":3,"city":"Nice"}
+//	{"version":1,"type":"iteration_completed","iteration":1,"alive":0,"dead":3}
+//	{"version":1,"type":"simulation_ended","iteration":1,"reason":"all-aliens-ko","alive":0,"trapped":1,"destroyed":1}
+//
+// The aliens of a fight are the attacker, then the defender.
+type jsonEvent struct {
+	Version   int                  `json:"version"`
+	Type      string               `json:"type"`
+	Iteration int                  `json:"iteration"`
+	Alien     uint                 `json:"alien,omitempty"`
+	City      string               `json:"city,omitempty"`
+	From      string               `json:"from,omitempty"`
+	To        string               `json:"to,omitempty"`
+	Aliens    []uint               `json:"aliens,omitempty"`
+	Borders   map[Direction]string `json:"borders,omitempty"`
+	Reason    string               `json:"reason,omitempty"`
+	Alive     *int                 `json:"alive,omitempty"`
+	Dead      *int                 `json:"dead,omitempty"`
+	Trapped   *int                 `json:"trapped,omitempty"`
+	Destroyed *int                 `json:"destroyed,omitempty"`
+}
+
+// Types of the events written by JSONObserver.
+const (
+	eventAlienPlaced        = "alien_placed"
+	eventAlienMoved         = "alien_moved"
+	eventFight              = "fight"
+	eventCityDestroyed      = "city_destroyed"
+	eventAlienTrapped       = "alien_trapped"
+	eventIterationCompleted = "iteration_completed"
+	eventSimulationEnded    = "simulation_ended"
+)
+
+func newJSONEvent(e Event) jsonEvent {
+	je := jsonEvent{Version: EventLogVersion}
+
+	switch e := e.(type) {
+	case AlienPlaced:
+		je.Type, je.Iteration, je.Alien, je.City = eventAlienPlaced, e.Iteration, e.Alien, e.City
+	case AlienMoved:
+		je.Type, je.Iteration, je.Alien, je.From, je.To = eventAlienMoved, e.Iteration, e.Alien, e.From, e.To
+	case FightOccurred:
+		je.Type, je.Iteration, je.City = eventFight, e.Iteration, e.City
+		je.Aliens = []uint{e.Attacker, e.Defender}
+	case CityDestroyed:
+		je.Type, je.Iteration, je.City = eventCityDestroyed, e.Iteration, e.City
+		je.Aliens = []uint{e.Aliens[0], e.Aliens[1]}
+		je.Borders = e.Borders
+	case AlienTrapped:
+		je.Type, je.Iteration, je.Alien, je.City = eventAlienTrapped, e.Iteration, e.Alien, e.City
+	case IterationCompleted:
+		je.Type, je.Iteration = eventIterationCompleted, e.Iteration
+		je.Alive, je.Dead = &e.Alive, &e.Dead
+	case SimulationEnded:
+		je.Type, je.Iteration, je.Reason = eventSimulationEnded, e.Iteration, e.Reason
+		je.Alive, je.Trapped, je.Destroyed = &e.Alive, &e.Trapped, &e.Destroyed
+	}
+
+	return je
+}
+
+// event converts the JSON representation back to an event.
+func (je jsonEvent) event() (Event, error) {
+	count := func(n *int) int {
+		if n == nil {
+			return 0
+		}
+		return *n
+	}
+
+	switch je.Type {
+	case eventAlienPlaced:
+		return AlienPlaced{Iteration: je.Iteration, Alien: je.Alien, City: je.City}, nil
+	case eventAlienMoved:
+		return AlienMoved{Iteration: je.Iteration, Alien: je.Alien, From: je.From, To: je.To}, nil
+	case eventFight, eventCityDestroyed:
+		if len(je.Aliens) != 2 {
+			return nil, fmt.Errorf("%s event: expected 2 aliens, got %d", je.Type, len(je.Aliens))
+		}
+
+		if je.Type == eventFight {
+			return FightOccurred{Iteration: je.Iteration, City: je.City, Attacker: je.Aliens[0], Defender: je.Aliens[1]}, nil
+		}
+
+		borders := je.Borders
+		if borders == nil {
+			borders = make(map[Direction]string)
+		}
+
+		return CityDestroyed{Iteration: je.Iteration, City: je.City, Borders: borders, Aliens: [2]uint{je.Aliens[0], je.Aliens[1]}}, nil
+	case eventAlienTrapped:
+		return AlienTrapped{Iteration: je.Iteration, Alien: je.Alien, City: je.City}, nil
+	case eventIterationCompleted:
+		return IterationCompleted{Iteration: je.Iteration, Alive: count(je.Alive), Dead: count(je.Dead)}, nil
+	case eventSimulationEnded:
+		return SimulationEnded{
+			Iteration: je.Iteration,
+			Reason:    je.Reason,
+			Alive:     count(je.Alive),
+			Trapped:   count(je.Trapped),
+			Destroyed: count(je.Destroyed),
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown event type `%s`", je.Type)
+}
+
+// JSONObserver writes every event as a JSON object on its own line (JSON
+// Lines), see EventLogVersion for the versioning of the schema.
+//
+// Events are buffered: Flush must be called once the simulation has ended.
+type JSONObserver struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+	err error
+}
+
+// NewJSONObserver returns a JSONObserver writing to w.
+func NewJSONObserver(w io.Writer) *JSONObserver {
+	bw := bufio.NewWriter(w)
+	return &JSONObserver{w: bw, enc: json.NewEncoder(bw)}
+}
+
+// Observe implements Observer. Events are dropped after the first write
+// error, which is returned by Flush.
+func (o *JSONObserver) Observe(e Event) {
+	if o.err != nil {
+		return
+	}
+
+	o.err = o.enc.Encode(newJSONEvent(e))
+}
+
+// Flush writes the buffered events and returns the first error encountered,
+// if any.
+func (o *JSONObserver) Flush() error {
+	if o.err != nil {
+		return o.err
+	}
+
+	return o.w.Flush()
+}
@@ -0,0 +1,62 @@
+package invader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONObserver(t *testing.T) {
+	var buf bytes.Buffer
+	observer := NewJSONObserver(&buf)
+
+	ai := NewAlienInvaders(testInvaderLogger, testInvaderDefaultWriter, WithSeed(1), WithObserver(observer))
+	err := ai.ParseMap(strings.NewReader("Paris north=Lille\nNice\n"))
+	require.NoError(t, err)
+
+	err = ai.GenerateAliens(3)
+	require.NoError(t, err)
+
+	err = ai.Run(context.Background(), 100)
+	require.Equal(t, ErrAllAliensAreKO, err)
+
+	err = observer.Flush()
+	require.NoError(t, err)
+
+	require.Equal(t, strings.Join([]string{
+		`{"version":1,"type":"alien_placed","iteration":0,"alien":1,"city":"Lille"}`,
+		`{"version":1,"type":"alien_placed","iteration":0,"alien":2,"city":"Nice"}`,
+		`{"version":1,"type":"alien_placed","iteration":0,"alien":3,"city":"Paris"}`,
+		`{"version":1,"type":"alien_moved","iteration":1,"alien":1,"from":"Lille","to":"Paris"}`,
+		`{"version":1,"type":"fight","iteration":1,"city":"Paris","aliens":[1,3]}`,
+		`{"version":1,"type":"city_destroyed","iteration":1,"city":"Paris","aliens":[1,3],"borders":{"north":"Lille"}}`,
+		`{"version":1,"type":"alien_trapped","iteration":1,"alien":2,"city":"Nice"}`,
+		`{"version":1,"type":"iteration_completed","iteration":1,"alive":0,"dead":3}`,
+		`{"version":1,"type":"simulation_ended","iteration":1,"reason":"all-aliens-ko","alive":0,"trapped":1,"destroyed":1}`,
+	}, "\n")+"\n", buf.String())
+
+	// Every line is a valid JSON object
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var event map[string]interface{}
+		err := json.Unmarshal([]byte(line), &event)
+		require.NoError(t, err)
+		require.Equal(t, float64(EventLogVersion), event["version"])
+	}
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, io.ErrClosedPipe
+}
+
+func TestJSONObserverError(t *testing.T) {
+	observer := NewJSONObserver(failingWriter{})
+	observer.Observe(AlienPlaced{Alien: 1, City: "a"})
+	require.ErrorIs(t, observer.Flush(), io.ErrClosedPipe)
+}
@@ -0,0 +1,313 @@
+package invader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+)
+
+// Fingerprint returns a hash identifying the map: two maps with the same
+// cities and borders have the same fingerprint, whatever the order of their
+// lines or their format. Headers and city attributes are ignored.
+func (cs Cities) Fingerprint() string {
+	h := sha256.New()
+	h.Write([]byte("invader-map\n"))
+	cs.Print(h)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// StructureFingerprint returns a hash identifying the structure of the map,
+// ignoring the city names: two maps have the same structure fingerprint if
+// the cities of one can be renamed to get the other.
+//
+// Each connected component is encoded by walking it breadth first from a
+// root, in the order of AllDirections, and numbering the cities in the order
+// they are visited. One-sided borders are followed backward too, after the
+// borders of the city, so cities only reachable through them are part of the
+// encoding. The smallest encoding over every possible root is the canonical
+// one. Roots are first narrowed down by color refinement, and the roots
+// equivalent to the best one by a symmetry of the map are skipped.
+func (cs Cities) StructureFingerprint() string {
+	g := cs.graph()
+	colors := g.refineColors()
+	incoming := g.incoming(colors)
+
+	encodings := make([][]byte, 0)
+	for _, component := range g.components() {
+		encodings = append(encodings, g.canonicalEncoding(component, colors, incoming))
+	}
+
+	sort.Slice(encodings, func(i, j int) bool {
+		return bytes.Compare(encodings[i], encodings[j]) < 0
+	})
+
+	h := sha256.New()
+	h.Write([]byte("invader-structure\n"))
+	for _, encoding := range encodings {
+		binary.Write(h, binary.BigEndian, uint64(len(encoding)))
+		h.Write(encoding)
+	}
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// components returns the connected components of the graph, following the
+// borders in both directions.
+func (g *graph) components() [][]int {
+	reverse := make([][]int, len(g.cities))
+	for i := range g.adj {
+		for _, j := range g.adj[i] {
+			if j >= 0 {
+				reverse[j] = append(reverse[j], i)
+			}
+		}
+	}
+
+	visited := make([]bool, len(g.cities))
+	var components [][]int
+	for root := range g.cities {
+		if visited[root] {
+			continue
+		}
+
+		visited[root] = true
+		component := []int{root}
+		for k := 0; k < len(component); k++ {
+			i := component[k]
+			visit := func(j int) {
+				if j >= 0 && !visited[j] {
+					visited[j] = true
+					component = append(component, j)
+				}
+			}
+
+			for _, j := range g.adj[i] {
+				visit(j)
+			}
+			for _, j := range reverse[i] {
+				visit(j)
+			}
+		}
+
+		components = append(components, component)
+	}
+
+	return components
+}
+
+// refineColors colors the cities so that two cities have the same color if
+// their neighbourhoods look the same, whatever the names: starting from the
+// directions each city has a border toward, colors are refined with the
+// colors of the neighbours until they are stable. Colors only depend on the
+// structure of the map.
+func (g *graph) refineColors() []int {
+	type signature [5]int
+
+	n := len(g.cities)
+	colors := make([]int, n)
+	for i := range colors {
+		for d, j := range g.adj[i] {
+			if j >= 0 {
+				colors[i] |= 1 << d
+			}
+		}
+	}
+
+	classes := countDistinct(colors)
+	signatures := make([]signature, n)
+	for {
+		for i := range signatures {
+			signatures[i][0] = colors[i]
+			for d, j := range g.adj[i] {
+				signatures[i][d+1] = -1
+				if j >= 0 {
+					signatures[i][d+1] = colors[j]
+				}
+			}
+		}
+
+		// Number the distinct signatures in sorted order, so colors don't
+		// depend on the order of the cities
+		order := make([]int, n)
+		for i := range order {
+			order[i] = i
+		}
+
+		sort.Slice(order, func(a, b int) bool {
+			return lessSignature(signatures[order[a]], signatures[order[b]])
+		})
+
+		next := make([]int, n)
+		color := 0
+		for k, i := range order {
+			if k > 0 && signatures[order[k-1]] != signatures[i] {
+				color++
+			}
+
+			next[i] = color
+		}
+
+		colors = next
+		if color+1 == classes {
+			return colors
+		}
+
+		classes = color + 1
+	}
+}
+
+func lessSignature(a, b [5]int) bool {
+	for k := range a {
+		if a[k] != b[k] {
+			return a[k] < b[k]
+		}
+	}
+
+	return false
+}
+
+func countDistinct(values []int) int {
+	seen := make(map[int]bool)
+	for _, v := range values {
+		seen[v] = true
+	}
+
+	return len(seen)
+}
+
+// incoming returns, for each city, the cities having a border toward it,
+// sorted by the direction of the border and then by color.
+func (g *graph) incoming(colors []int) [][]int {
+	type border struct{ from, dir int }
+
+	borders := make([][]border, len(g.cities))
+	for i := range g.adj {
+		for d, j := range g.adj[i] {
+			if j >= 0 {
+				borders[j] = append(borders[j], border{from: i, dir: d})
+			}
+		}
+	}
+
+	incoming := make([][]int, len(g.cities))
+	for j, bs := range borders {
+		sort.SliceStable(bs, func(a, b int) bool {
+			if bs[a].dir != bs[b].dir {
+				return bs[a].dir < bs[b].dir
+			}
+
+			return colors[bs[a].from] < colors[bs[b].from]
+		})
+
+		for _, b := range bs {
+			incoming[j] = append(incoming[j], b.from)
+		}
+	}
+
+	return incoming
+}
+
+// encode walks the graph breadth first from the root and returns the cities
+// in the order they have been visited along with the encoding of the walk:
+// for each visited city, the visit number of its neighbour in each direction,
+// 0 if there is none. The cities bordering a visited city are visited after
+// its neighbours, so the whole component is walked even with one-sided
+// borders. The given number slice must be filled with zeros, and is reset
+// before returning.
+func (g *graph) encode(root int, incoming [][]int, number []int) (order []int, encoding []byte) {
+	order = []int{root}
+	number[root] = 1
+	visit := func(j int) {
+		if number[j] == 0 {
+			order = append(order, j)
+			number[j] = len(order)
+		}
+	}
+
+	var buf [4]byte
+	for k := 0; k < len(order); k++ {
+		i := order[k]
+		for _, j := range g.adj[i] {
+			v := 0
+			if j >= 0 {
+				visit(j)
+				v = number[j]
+			}
+
+			binary.BigEndian.PutUint32(buf[:], uint32(v))
+			encoding = append(encoding, buf[:]...)
+		}
+
+		// The borders toward the city are already encoded by the cities they
+		// start from, they only need to be visited
+		for _, j := range incoming[i] {
+			visit(j)
+		}
+	}
+
+	for _, i := range order {
+		number[i] = 0
+	}
+
+	return order, encoding
+}
+
+// canonicalEncoding returns the smallest encoding of the component over every
+// possible root. Only the roots of the rarest color of the component are
+// tried, since an encoding determines the color of its root.
+func (g *graph) canonicalEncoding(component []int, colors []int, incoming [][]int) []byte {
+	count := make(map[int]int)
+	for _, i := range component {
+		count[colors[i]]++
+	}
+
+	rarest := colors[component[0]]
+	for color, n := range count {
+		if n < count[rarest] || (n == count[rarest] && color < rarest) {
+			rarest = color
+		}
+	}
+
+	number := make([]int, len(g.cities))
+	// orbit holds the roots known to give the same encoding as the best one
+	orbit := make(map[int]bool)
+	var bestOrder []int
+	var best []byte
+	for _, root := range component {
+		if colors[root] != rarest || orbit[root] {
+			continue
+		}
+
+		order, encoding := g.encode(root, incoming, number)
+		switch cmp := bytes.Compare(encoding, best); {
+		case best == nil || cmp < 0:
+			best, bestOrder = encoding, order
+			orbit = map[int]bool{root: true}
+		case cmp == 0:
+			// The walks from both roots match city by city: this is a symmetry
+			// of the component, add the images of the known roots to the orbit
+			symmetry := make(map[int]int, len(order))
+			for k, i := range bestOrder {
+				symmetry[i] = order[k]
+			}
+
+			queue := make([]int, 0, len(orbit))
+			for i := range orbit {
+				queue = append(queue, i)
+			}
+
+			for len(queue) > 0 {
+				i := queue[len(queue)-1]
+				queue = queue[:len(queue)-1]
+				if image, ok := symmetry[i]; ok && !orbit[image] {
+					orbit[image] = true
+					queue = append(queue, image)
+				}
+			}
+		}
+	}
+
+	return best
+}
@@ -0,0 +1,76 @@
+package invader
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func parseMap(t *testing.T, input string) Cities {
+	t.Helper()
+
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader(input))
+	require.NoError(t, err)
+	return cities
+}
+
+func TestFingerprint(t *testing.T) {
+	a := parseMap(t, "a north=b\nb east=c\n")
+	b := parseMap(t, "c west=b\nb south=a\n")
+	require.Equal(t, a.Fingerprint(), b.Fingerprint())
+
+	renamed := parseMap(t, "x north=y\ny east=z\n")
+	require.NotEqual(t, a.Fingerprint(), renamed.Fingerprint())
+	require.Equal(t, a.StructureFingerprint(), renamed.StructureFingerprint())
+}
+
+func TestStructureFingerprint(t *testing.T) {
+	testCases := []struct {
+		Name string
+		A, B string
+		Same bool
+	}{
+		{Name: "renamed", A: "a north=b\nb east=c\n", B: "z north=y\ny east=x\n", Same: true},
+		{Name: "components order", A: "a north=b\nc east=d\n", B: "x east=y\nz north=w\n", Same: true},
+		{Name: "different direction", A: "a north=b\n", B: "a east=b\n", Same: false},
+		{Name: "extra city", A: "a north=b\n", B: "a north=b\nc\n", Same: false},
+		{Name: "one-sided borders", A: "a north=b\nc north=b\na east=x\n", B: "a north=b\nc north=b\na west=x\n", Same: false},
+		{Name: "one-sided chain", A: "a north=b\nc north=b\na east=x\n", B: "a north=b\nc north=b\na east=x\nx east=y\ny east=z\n", Same: false},
+		{Name: "renamed one-sided borders", A: "a north=b\nc north=b\na east=x\n", B: "r north=p\nq north=p\nr east=s\n", Same: true},
+		{Name: "loop and line", A: "a north=b east=d\nb east=c\nc south=d\n", B: "a north=b\nb east=c\nc south=d\n", Same: false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			a, b := parseMap(t, tc.A), parseMap(t, tc.B)
+			if tc.Same {
+				require.Equal(t, a.StructureFingerprint(), b.StructureFingerprint())
+			} else {
+				require.NotEqual(t, a.StructureFingerprint(), b.StructureFingerprint())
+			}
+		})
+	}
+}
+
+func TestStructureFingerprintSymmetric(t *testing.T) {
+	// Every city of a torus looks the same
+	torus := func(size int, name func(x, y int) string) Cities {
+		cities := NewCities()
+		for x := 0; x < size; x++ {
+			for y := 0; y < size; y++ {
+				city := cities.GetOrCreate(name(x, y))
+				city.SetDirection(East, cities.GetOrCreate(name((x+1)%size, y)))
+				city.SetDirection(North, cities.GetOrCreate(name(x, (y+1)%size)))
+			}
+		}
+		return cities
+	}
+
+	a := torus(30, func(x, y int) string { return fmt.Sprintf("city_%d_%d", x, y) })
+	b := torus(30, func(x, y int) string { return fmt.Sprintf("%d:%d", (y*7)%30, (x*11)%30) })
+	require.Equal(t, a.StructureFingerprint(), b.StructureFingerprint())
+	require.NotEqual(t, a.StructureFingerprint(), torus(29, func(x, y int) string { return fmt.Sprint(x, y) }).StructureFingerprint())
+}
@@ -0,0 +1,484 @@
+package invader
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// cityName returns the default name of the i-th generated city, starting
+// at 0.
+func cityName(i int) string {
+	return "city_" + strconv.Itoa(i+1)
+}
+
+// Generator builds a random map.
+type Generator interface {
+	// Generate populates the cities using the given random generator, so the
+	// same seed always gives the same map.
+	Generate(cs Cities, r *Rand) error
+}
+
+// WalkGenerator grows a map by walking randomly from a random city of a
+// square grid, up to Depth steps away, each new city branching toward one to
+// three random directions.
+type WalkGenerator struct {
+	Depth int
+}
+
+func (g WalkGenerator) Generate(cs Cities, r *Rand) error {
+	if g.Depth <= 0 {
+		return fmt.Errorf("depth must be positive: %d", g.Depth)
+	}
+
+	// Initialize a square grid of pointers to City
+	depth := g.Depth
+	table := make([][]*City, depth)
+	for y := range table {
+		table[y] = make([]*City, depth)
+	}
+
+	// Generate random starting position
+	x, y := r.Intn(depth), r.Intn(depth)
+
+	// Helper function to generate city name
+	var counterID int
+	genid := func() string {
+		counterID++
+		return cityName(counterID - 1)
+	}
+
+	// Helper function to calculate new position based on direction
+	calculateNewPosition := func(dir Direction) (newx int, newy int) {
+		switch dir {
+		case North:
+			return x - 1, y
+		case South:
+			return x + 1, y
+		case East:
+			return x, y + 1
+		case West:
+			return x, y - 1
+		default:
+			return x, y
+		}
+	}
+
+	// Create root city and add it to cities map and grid
+	root := NewCity(genid())
+	cs[root.Name] = root
+	table[y][x] = root
+
+	// Initialize distance from root
+	distance := 0
+
+	// Recursive function to generate cities and connections
+	var citygen func(dir Direction, c *City)
+	citygen = func(dir Direction, c *City) {
+		// If we've reached the maximum depth, stop
+		if distance > depth {
+			return
+		}
+
+		distance++
+
+		// Remember the current position
+		oldx, oldy := x, y
+
+		// Calculate new position based on direction
+		x, y = calculateNewPosition(dir)
+
+		// If the new position is within the grid
+		if x >= 0 && x < depth && y >= 0 && y < depth {
+			// If a city already exists at the new position, connect it
+			if table[y][x] != nil {
+				c.borderCities[dir] = table[y][x]
+				table[y][x].borderCities[dir.Opposite()] = c
+			} else {
+				// Create a new city and add it to cities map and grid
+				newcity := NewCity(genid())
+				table[y][x] = newcity
+				cs[newcity.Name] = newcity
+
+				// Connect the new city
+				c.borderCities[dir] = newcity
+				newcity.borderCities[dir.Opposite()] = c
+
+				// Generate a random list of directions, with a random length of 1 or more
+				ad := make([]Direction, len(AllDirections))
+				copy(ad, AllDirections)
+				r.Shuffle(len(ad), func(i, j int) { ad[i], ad[j] = ad[j], ad[i] })
+				ad = ad[0 : r.Intn(len(AllDirections)-1)+1]
+
+				// Recursively generate cities in these directions
+				for _, dir := range ad {
+					citygen(dir, newcity)
+				}
+			}
+		}
+
+		distance--
+		// Return to previous position before returning
+		x, y = oldx, oldy
+	}
+
+	// Start generating cities in all directions from the root
+	for _, dir := range AllDirections {
+		citygen(dir, root)
+	}
+
+	return nil
+}
+
+// GridShape is the shape of the grid used by grid based generators.
+type GridShape struct {
+	Width, Height int
+	// Torus wraps the grid around: cities of the first and last columns, and
+	// of the first and last rows, border each other. Both dimensions must be
+	// at least 3.
+	Torus bool
+}
+
+// cityGrid lays out cities on a grid, row 0 being the northern one. The city
+// at (x, y) is named `city_<y*width+x+1>`.
+type cityGrid struct {
+	GridShape
+	cities []*City
+}
+
+func (shape GridShape) newGrid(cs Cities) (*cityGrid, error) {
+	if shape.Width <= 0 || shape.Height <= 0 {
+		return nil, fmt.Errorf("grid dimensions must be positive: %dx%d", shape.Width, shape.Height)
+	}
+
+	if shape.Torus && (shape.Width < 3 || shape.Height < 3) {
+		return nil, fmt.Errorf("torus dimensions must be at least 3x3: %dx%d", shape.Width, shape.Height)
+	}
+
+	g := &cityGrid{GridShape: shape, cities: make([]*City, shape.Width*shape.Height)}
+	for i := range g.cities {
+		g.cities[i] = cs.GetOrCreate(cityName(i))
+	}
+
+	return g, nil
+}
+
+// neighbor returns the index of the cell next to the given one in the given
+// direction, or -1 if it is out of the grid.
+func (g *cityGrid) neighbor(i int, dir Direction) int {
+	x, y := i%g.Width, i/g.Width
+	switch dir {
+	case North:
+		y--
+	case South:
+		y++
+	case East:
+		x++
+	case West:
+		x--
+	}
+
+	if g.Torus {
+		x, y = (x+g.Width)%g.Width, (y+g.Height)%g.Height
+	}
+
+	if x < 0 || x >= g.Width || y < 0 || y >= g.Height {
+		return -1
+	}
+
+	return y*g.Width + x
+}
+
+// gridEdge is a potential border of the grid, from a cell toward the east or
+// the south.
+type gridEdge struct {
+	from int
+	dir  Direction
+}
+
+// edges returns every potential border of the grid, row by row.
+func (g *cityGrid) edges() []gridEdge {
+	edges := make([]gridEdge, 0, 2*len(g.cities))
+	for i := range g.cities {
+		for _, dir := range []Direction{East, South} {
+			if g.neighbor(i, dir) >= 0 {
+				edges = append(edges, gridEdge{from: i, dir: dir})
+			}
+		}
+	}
+
+	return edges
+}
+
+func (g *cityGrid) link(e gridEdge) {
+	g.cities[e.from].SetDirection(e.dir, g.cities[g.neighbor(e.from, e.dir)])
+}
+
+// GridGenerator builds a full grid, each city bordering all of its
+// neighbours.
+type GridGenerator struct {
+	GridShape
+}
+
+func (g GridGenerator) Generate(cs Cities, r *Rand) error {
+	return generateRows(cs, g, r)
+}
+
+// MazeAlgorithm is the algorithm used to carve a maze.
+type MazeAlgorithm string
+
+const (
+	// MazeDFS carves long winding corridors with few dead ends, using a
+	// randomized depth first search.
+	MazeDFS MazeAlgorithm = "dfs"
+	// MazePrim carves short corridors with many dead ends, using a randomized
+	// version of Prim's algorithm.
+	MazePrim MazeAlgorithm = "prim"
+	// MazeEller carves the maze row by row using Eller's algorithm, so it can
+	// be streamed (see StreamGenerator). It doesn't support tori.
+	MazeEller MazeAlgorithm = "eller"
+)
+
+// MazeGenerator builds a perfect maze on a grid: every city can be reached
+// from any other by exactly one path.
+type MazeGenerator struct {
+	GridShape
+	Algorithm MazeAlgorithm
+}
+
+func (g MazeGenerator) Generate(cs Cities, r *Rand) error {
+	if g.Algorithm == MazeEller {
+		return generateRows(cs, g, r)
+	}
+
+	grid, err := g.newGrid(cs)
+	if err != nil {
+		return err
+	}
+
+	visited := make([]bool, len(grid.cities))
+	start := r.Intn(len(grid.cities))
+	visited[start] = true
+
+	switch g.Algorithm {
+	case MazeDFS:
+		stack := []int{start}
+		for len(stack) > 0 {
+			current := stack[len(stack)-1]
+
+			var candidates []Direction
+			for _, dir := range AllDirections {
+				if next := grid.neighbor(current, dir); next >= 0 && !visited[next] {
+					candidates = append(candidates, dir)
+				}
+			}
+
+			if len(candidates) == 0 {
+				stack = stack[:len(stack)-1]
+				continue
+			}
+
+			dir := candidates[r.Intn(len(candidates))]
+			next := grid.neighbor(current, dir)
+			grid.link(gridEdge{from: current, dir: dir})
+			visited[next] = true
+			stack = append(stack, next)
+		}
+	case MazePrim:
+		var frontier []gridEdge
+		addFrontier := func(i int) {
+			for _, dir := range AllDirections {
+				if next := grid.neighbor(i, dir); next >= 0 && !visited[next] {
+					frontier = append(frontier, gridEdge{from: i, dir: dir})
+				}
+			}
+		}
+
+		addFrontier(start)
+		for len(frontier) > 0 {
+			k := r.Intn(len(frontier))
+			e := frontier[k]
+			frontier[k] = frontier[len(frontier)-1]
+			frontier = frontier[:len(frontier)-1]
+
+			next := grid.neighbor(e.from, e.dir)
+			if visited[next] {
+				continue
+			}
+
+			grid.link(e)
+			visited[next] = true
+			addFrontier(next)
+		}
+	default:
+		return fmt.Errorf("unknown maze algorithm `%s`", g.Algorithm)
+	}
+
+	return nil
+}
+
+// PercolationGenerator builds a grid where each possible border exists with
+// the probability Density, which leaves some cities isolated or disconnected
+// when the density is low.
+type PercolationGenerator struct {
+	GridShape
+	Density float64
+}
+
+func (g PercolationGenerator) Generate(cs Cities, r *Rand) error {
+	return generateRows(cs, g, r)
+}
+
+// TreeGenerator builds a random spanning tree of a grid, using a randomized
+// version of Kruskal's algorithm, then adds Loops random borders to create
+// cycles.
+type TreeGenerator struct {
+	GridShape
+	Loops int
+}
+
+func (g TreeGenerator) Generate(cs Cities, r *Rand) error {
+	if g.Loops < 0 {
+		return fmt.Errorf("number of loops cannot be negative: %d", g.Loops)
+	}
+
+	grid, err := g.newGrid(cs)
+	if err != nil {
+		return err
+	}
+
+	edges := grid.edges()
+	r.Shuffle(len(edges), func(i, j int) { edges[i], edges[j] = edges[j], edges[i] })
+
+	// Union-find of the cities already connected
+	parent := make([]int, len(grid.cities))
+	for i := range parent {
+		parent[i] = i
+	}
+
+	var find func(i int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	var unused []gridEdge
+	for _, e := range edges {
+		a, b := find(e.from), find(grid.neighbor(e.from, e.dir))
+		if a == b {
+			unused = append(unused, e)
+			continue
+		}
+
+		parent[a] = b
+		grid.link(e)
+	}
+
+	// Unused borders are already shuffled
+	if g.Loops > len(unused) {
+		g.Loops = len(unused)
+	}
+
+	for _, e := range unused[:g.Loops] {
+		grid.link(e)
+	}
+
+	return nil
+}
+
+// GrowthGenerator builds a connected map of exactly Cities cities. Starting
+// from a single city, new cities are added one by one next to a random city
+// of the map, bordering it, and border each of their other neighbours with
+// the probability Density. If Width or Height is set, cities are kept within
+// this number of columns or rows.
+type GrowthGenerator struct {
+	Cities        int
+	Width, Height int
+	Density       float64
+}
+
+func (g GrowthGenerator) Generate(cs Cities, r *Rand) error {
+	if g.Cities <= 0 {
+		return fmt.Errorf("number of cities must be positive: %d", g.Cities)
+	}
+
+	if g.Density < 0 || g.Density > 1 {
+		return fmt.Errorf("density must be between 0 and 1: %g", g.Density)
+	}
+
+	if g.Width < 0 || g.Height < 0 {
+		return fmt.Errorf("grid size cannot be negative: %dx%d", g.Width, g.Height)
+	}
+
+	if g.Width > 0 && g.Height > 0 && g.Cities > g.Width*g.Height {
+		return fmt.Errorf("%d cities don't fit in a %dx%d grid", g.Cities, g.Width, g.Height)
+	}
+
+	inside := func(p Point) bool {
+		return (g.Width == 0 || (p.X >= 0 && p.X < g.Width)) &&
+			(g.Height == 0 || (p.Y >= 0 && p.Y < g.Height))
+	}
+
+	placed := make(map[Point]*City, g.Cities)
+	// Free cells next to a city, in a slice to pick one at random
+	var frontier []Point
+	inFrontier := make(map[Point]int)
+	place := func(p Point) *City {
+		city := cs.GetOrCreate(cityName(len(placed)))
+		placed[p] = city
+
+		if k, ok := inFrontier[p]; ok {
+			last := frontier[len(frontier)-1]
+			frontier[k], inFrontier[last] = last, k
+			frontier = frontier[:len(frontier)-1]
+			delete(inFrontier, p)
+		}
+
+		for _, dir := range AllDirections {
+			next := p.Move(dir)
+			if _, ok := placed[next]; ok || !inside(next) {
+				continue
+			}
+
+			if _, ok := inFrontier[next]; !ok {
+				inFrontier[next] = len(frontier)
+				frontier = append(frontier, next)
+			}
+		}
+
+		return city
+	}
+
+	start := Point{}
+	if g.Width > 0 {
+		start.X = r.Intn(g.Width)
+	}
+	if g.Height > 0 {
+		start.Y = r.Intn(g.Height)
+	}
+	place(start)
+
+	for len(placed) < g.Cities {
+		p := frontier[r.Intn(len(frontier))]
+		city := place(p)
+
+		// Border one of the neighbours to stay connected, the other ones
+		// with the given probability
+		var neighbors []Direction
+		for _, dir := range AllDirections {
+			if _, ok := placed[p.Move(dir)]; ok {
+				neighbors = append(neighbors, dir)
+			}
+		}
+
+		first := r.Intn(len(neighbors))
+		for k, dir := range neighbors {
+			if k == first || r.Float64() < g.Density {
+				city.SetDirection(dir, placed[p.Move(dir)])
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,134 @@
+package invader
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerators(t *testing.T) {
+	const size = 8
+	n := size * size
+	gridBorders := 2 * size * (size - 1)
+	square := GridShape{Width: size, Height: size}
+	rectangle := GridShape{Width: 5, Height: 3}
+	torus := GridShape{Width: size, Height: size, Torus: true}
+
+	testCases := []struct {
+		Name           string
+		Generator      Generator
+		WantCities     int
+		WantBorders    int // -1 if random
+		WantComponents int // -1 if random
+	}{
+		{Name: "walk", Generator: WalkGenerator{Depth: size}, WantBorders: -1, WantComponents: 1},
+		{Name: "grid", Generator: GridGenerator{GridShape: square}, WantCities: n, WantBorders: gridBorders, WantComponents: 1},
+		{Name: "maze dfs", Generator: MazeGenerator{GridShape: square, Algorithm: MazeDFS}, WantCities: n, WantBorders: n - 1, WantComponents: 1},
+		{Name: "maze prim", Generator: MazeGenerator{GridShape: square, Algorithm: MazePrim}, WantCities: n, WantBorders: n - 1, WantComponents: 1},
+		{Name: "empty percolation", Generator: PercolationGenerator{GridShape: square, Density: 0}, WantCities: n, WantBorders: 0, WantComponents: n},
+		{Name: "full percolation", Generator: PercolationGenerator{GridShape: square, Density: 1}, WantCities: n, WantBorders: gridBorders, WantComponents: 1},
+		{Name: "percolation", Generator: PercolationGenerator{GridShape: square, Density: 0.5}, WantCities: n, WantBorders: -1, WantComponents: -1},
+		{Name: "tree", Generator: TreeGenerator{GridShape: square, Loops: 5}, WantCities: n, WantBorders: n - 1 + 5, WantComponents: 1},
+		{Name: "rectangle", Generator: GridGenerator{GridShape: rectangle}, WantCities: 15, WantBorders: 4*3 + 5*2, WantComponents: 1},
+		{Name: "torus", Generator: GridGenerator{GridShape: torus}, WantCities: n, WantBorders: 2 * n, WantComponents: 1},
+		{Name: "torus maze", Generator: MazeGenerator{GridShape: torus, Algorithm: MazeDFS}, WantCities: n, WantBorders: n - 1, WantComponents: 1},
+		{Name: "growth", Generator: GrowthGenerator{Cities: 50, Density: 0.5}, WantCities: 50, WantBorders: -1, WantComponents: 1},
+		{Name: "growth tree", Generator: GrowthGenerator{Cities: 50}, WantCities: 50, WantBorders: 49, WantComponents: 1},
+		{Name: "bounded growth", Generator: GrowthGenerator{Cities: 15, Width: 5, Height: 3, Density: 1}, WantCities: 15, WantBorders: 4*3 + 5*2, WantComponents: 1},
+		{Name: "growth in a row", Generator: GrowthGenerator{Cities: 10, Height: 1}, WantCities: 10, WantBorders: 9, WantComponents: 1},
+		{Name: "tree with too many loops", Generator: TreeGenerator{GridShape: square, Loops: 1000}, WantCities: n, WantBorders: gridBorders, WantComponents: 1},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cities := NewCities()
+			err := tc.Generator.Generate(cities, NewRand(42))
+			require.NoError(t, err)
+
+			if tc.WantCities > 0 {
+				require.Len(t, cities, tc.WantCities)
+			}
+
+			stats := cities.Stats()
+			if tc.WantBorders >= 0 {
+				require.Equal(t, tc.WantBorders, stats.Borders)
+			}
+			if tc.WantComponents >= 0 {
+				require.Len(t, stats.Components, tc.WantComponents)
+			}
+
+			// Tori cannot be drawn flat
+			if !strings.HasPrefix(tc.Name, "torus") {
+				require.Empty(t, cities.Layout().Conflicts)
+			}
+
+			// The same source gives the same map
+			again := NewCities()
+			err = tc.Generator.Generate(again, NewRand(42))
+			require.NoError(t, err)
+			require.Equal(t, cities.Fingerprint(), again.Fingerprint())
+		})
+	}
+}
+
+func TestGeneratorsError(t *testing.T) {
+	for _, generator := range []Generator{
+		WalkGenerator{Depth: 0},
+		GridGenerator{GridShape: GridShape{Width: -1, Height: 4}},
+		GridGenerator{GridShape: GridShape{Width: 2, Height: 4, Torus: true}},
+		MazeGenerator{GridShape: GridShape{Width: 4, Height: 4}, Algorithm: "unknown"},
+		PercolationGenerator{GridShape: GridShape{Width: 4, Height: 4}, Density: 1.5},
+		TreeGenerator{GridShape: GridShape{Width: 4, Height: 4}, Loops: -1},
+		GrowthGenerator{Cities: 0},
+		GrowthGenerator{Cities: 10, Width: 3, Height: 3},
+	} {
+		err := generator.Generate(NewCities(), NewRand(1))
+		require.Error(t, err, "%#v", generator)
+	}
+}
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// TestGeneratorsGolden ensures a given seed always generates the same map, see
+// testdata/golden. Run `go test -run Golden -update` to update the files after
+// an intended change.
+func TestGeneratorsGolden(t *testing.T) {
+	square := GridShape{Width: 6, Height: 6}
+	generators := map[string]Generator{
+		"walk":        WalkGenerator{Depth: 6},
+		"grid":        GridGenerator{GridShape: GridShape{Width: 4, Height: 3}},
+		"maze-dfs":    MazeGenerator{GridShape: square, Algorithm: MazeDFS},
+		"maze-prim":   MazeGenerator{GridShape: square, Algorithm: MazePrim},
+		"maze-eller":  MazeGenerator{GridShape: square, Algorithm: MazeEller},
+		"percolation": PercolationGenerator{GridShape: square, Density: 0.5},
+		"torus":       PercolationGenerator{GridShape: GridShape{Width: 5, Height: 5, Torus: true}, Density: 0.5},
+		"tree":        TreeGenerator{GridShape: square, Loops: 3},
+		"growth":      GrowthGenerator{Cities: 30, Density: 0.3},
+	}
+
+	for name, generator := range generators {
+		t.Run(name, func(t *testing.T) {
+			cities := NewCities()
+			err := generator.Generate(cities, NewRand(SeedFromString("golden")))
+			require.NoError(t, err)
+
+			var buf bytes.Buffer
+			cities.Print(&buf)
+
+			path := filepath.Join("testdata", "golden", name+".map")
+			if *update {
+				err := os.WriteFile(path, buf.Bytes(), 0o644)
+				require.NoError(t, err)
+			}
+
+			want, err := os.ReadFile(path)
+			require.NoError(t, err)
+			require.Equal(t, string(want), buf.String())
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package invader
+
+import "fmt"
+
+// Point is a position on the grid, X growing toward the east and Y toward the
+// north.
+type Point struct {
+	X, Y int
+}
+
+// Move returns the point next to p in the given direction.
+func (p Point) Move(dir Direction) Point {
+	switch dir {
+	case North:
+		p.Y++
+	case South:
+		p.Y--
+	case East:
+		p.X++
+	case West:
+		p.X--
+	}
+
+	return p
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("(%d, %d)", p.X, p.Y)
+}
+
+// GeometryConflictKind describes why a map cannot be drawn on a grid.
+type GeometryConflictKind int
+
+const (
+	// GeometryMismatch is reported when a border leads to a city already
+	// placed somewhere else, e.g. going north, east, south then west doesn't
+	// come back to the starting city.
+	GeometryMismatch GeometryConflictKind = iota
+	// GeometryOverlap is reported when two cities end up at the same place.
+	GeometryOverlap
+)
+
+func (k GeometryConflictKind) String() string {
+	switch k {
+	case GeometryMismatch:
+		return "mismatch"
+	case GeometryOverlap:
+		return "overlap"
+	}
+
+	return fmt.Sprintf("GeometryConflictKind(%d)", int(k))
+}
+
+// GeometryConflict is a border that contradicts the position of the cities.
+type GeometryConflict struct {
+	Kind GeometryConflictKind
+	// The border that could not be laid out.
+	City      *City
+	Direction Direction
+	Neighbor  *City
+	// Other is the city already placed where Neighbor should be, for overlaps.
+	Other *City
+	// Expected is where the border leads to, Actual where Neighbor is.
+	Expected, Actual Point
+}
+
+func (c GeometryConflict) String() string {
+	switch c.Kind {
+	case GeometryOverlap:
+		return fmt.Sprintf("`%s` %s=`%s` places `%s` at %s, already taken by `%s`",
+			c.City.Name, c.Direction, c.Neighbor.Name, c.Neighbor.Name, c.Expected, c.Other.Name)
+	default:
+		return fmt.Sprintf("`%s` %s=`%s` leads to %s but `%s` is at %s",
+			c.City.Name, c.Direction, c.Neighbor.Name, c.Expected, c.Neighbor.Name, c.Actual)
+	}
+}
+
+// Layout holds the positions of the cities on a grid.
+type Layout struct {
+	// Positions holds the position of every city. Components are laid out
+	// side by side, from west to east, starting at (0, 0) in the south west
+	// corner.
+	Positions map[*City]Point
+	// Conflicts holds the borders that contradict the positions, in the order
+	// they have been found.
+	Conflicts []GeometryConflict
+}
+
+// Width returns the number of columns used by the layout.
+func (l Layout) Width() (width int) {
+	for _, p := range l.Positions {
+		if p.X+1 > width {
+			width = p.X + 1
+		}
+	}
+
+	return
+}
+
+// Height returns the number of rows used by the layout.
+func (l Layout) Height() (height int) {
+	for _, p := range l.Positions {
+		if p.Y+1 > height {
+			height = p.Y + 1
+		}
+	}
+
+	return
+}
+
+// Layout assigns grid coordinates to the cities by walking their borders,
+// northern borders increasing Y and eastern borders increasing X. The first
+// city of each component, in the order of Components, is placed first and
+// the borders are walked breadth first in the order of AllDirections, the
+// first position found for a city being kept. Borders contradicting the
+// positions are reported as conflicts.
+func (cs Cities) Layout() Layout {
+	layout := Layout{
+		Positions: make(map[*City]Point, len(cs)),
+		Conflicts: []GeometryConflict{},
+	}
+
+	type border struct {
+		from, to *City
+	}
+
+	// Mismatches are reported once per border, whatever side it is walked from
+	reported := make(map[border]bool)
+	offset := 0
+	for _, component := range cs.Components() {
+		positions := map[*City]Point{component[0]: {}}
+		occupied := map[Point]*City{{}: component[0]}
+		queue := []*City{component[0]}
+		for i := 0; i < len(queue); i++ {
+			city := queue[i]
+			city.IterateBorder(func(dir Direction, neighbor *City) {
+				expected := positions[city].Move(dir)
+				actual, placed := positions[neighbor]
+				switch {
+				case placed && actual != expected:
+					if reported[border{city, neighbor}] {
+						return
+					}
+
+					reported[border{city, neighbor}], reported[border{neighbor, city}] = true, true
+					layout.Conflicts = append(layout.Conflicts, GeometryConflict{
+						Kind: GeometryMismatch, City: city, Direction: dir, Neighbor: neighbor,
+						Expected: expected, Actual: actual,
+					})
+				case placed:
+				default:
+					if other, ok := occupied[expected]; ok {
+						layout.Conflicts = append(layout.Conflicts, GeometryConflict{
+							Kind: GeometryOverlap, City: city, Direction: dir, Neighbor: neighbor, Other: other,
+							Expected: expected, Actual: expected,
+						})
+					} else {
+						occupied[expected] = neighbor
+					}
+
+					positions[neighbor] = expected
+					queue = append(queue, neighbor)
+				}
+			})
+		}
+
+		// Move the component to the right of the previous one
+		lo, hi := Point{}, Point{}
+		for _, p := range positions {
+			lo.X, lo.Y = minInt(lo.X, p.X), minInt(lo.Y, p.Y)
+			hi.X = maxInt(hi.X, p.X)
+		}
+
+		for city, p := range positions {
+			layout.Positions[city] = Point{X: p.X - lo.X + offset, Y: p.Y - lo.Y}
+		}
+
+		offset += hi.X - lo.X + 2
+	}
+
+	return layout
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
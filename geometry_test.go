@@ -0,0 +1,73 @@
+package invader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLayout(t *testing.T) {
+	// b - c
+	// |   |   e - f
+	// a - d
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a north=b east=d\nb east=c\nc south=d\ne east=f\n"))
+	require.NoError(t, err)
+
+	layout := cities.Layout()
+	require.Empty(t, layout.Conflicts)
+	require.Equal(t, 5, layout.Width())
+	require.Equal(t, 2, layout.Height())
+
+	want := map[string]Point{
+		"a": {0, 0}, "b": {0, 1}, "c": {1, 1}, "d": {1, 0},
+		// Next component, leaving an empty column
+		"e": {3, 0}, "f": {4, 0},
+	}
+	for name, p := range want {
+		require.Equal(t, p, layout.Positions[cities[name]], "city `%s`", name)
+	}
+}
+
+func TestLayoutConflicts(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		WantKind GeometryConflictKind
+		Want     string
+	}{
+		{
+			Name:     "mismatch",
+			Input:    "a north=b\nb east=c\nc south=d\nd east=a\n",
+			WantKind: GeometryMismatch,
+			Want:     "`d` north=`c` leads to (-1, 1) but `c` is at (1, 1)",
+		},
+		{
+			Name:     "overlap",
+			Input:    "a north=b east=e\nb east=c\nc south=d\n",
+			WantKind: GeometryOverlap,
+			Want:     "`c` south=`d` places `d` at (1, 0), already taken by `e`",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cities := NewCities()
+			err := cities.Parse(strings.NewReader(tc.Input))
+			require.NoError(t, err)
+
+			layout := cities.Layout()
+			require.Len(t, layout.Conflicts, 1)
+			require.Equal(t, tc.WantKind, layout.Conflicts[0].Kind)
+			require.Equal(t, tc.Want, layout.Conflicts[0].String())
+		})
+	}
+}
+
+func TestLayoutGenerated(t *testing.T) {
+	cities := NewCities()
+	err := cities.GenerateRandomCity(4, NewRand(1))
+	require.NoError(t, err)
+	require.Empty(t, cities.Layout().Conflicts)
+}
@@ -7,6 +7,8 @@ require (
 	github.com/peterbourgon/ff/v3 v3.3.1
 	// Use for make test more readable
 	github.com/stretchr/testify v1.8.3
+	// Use to normalize unicode city names
+	golang.org/x/text v0.14.0
 )
 
 require (
@@ -0,0 +1,333 @@
+package invader
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// graph is a compact, index based, representation of the map used by graph
+// algorithms. Cities are indexed in the order of GetAll.
+type graph struct {
+	cities []*City
+	index  map[*City]int
+	// adj holds the index of the neighbour in each direction of AllDirections,
+	// or -1 if there is none.
+	adj [][4]int
+}
+
+func (cs Cities) graph() *graph {
+	g := &graph{
+		cities: cs.GetAll(),
+		index:  make(map[*City]int, len(cs)),
+	}
+
+	for i, city := range g.cities {
+		g.index[city] = i
+	}
+
+	g.adj = make([][4]int, len(g.cities))
+	for i, city := range g.cities {
+		for d, dir := range AllDirections {
+			g.adj[i][d] = -1
+			if neighbor, ok := city.GetDirection(dir); ok {
+				if j, ok := g.index[neighbor]; ok {
+					g.adj[i][d] = j
+				}
+			}
+		}
+	}
+
+	return g
+}
+
+// newDistances returns a slice of distances suitable for bfs.
+func (g *graph) newDistances() []int {
+	dist := make([]int, len(g.cities))
+	for i := range dist {
+		dist[i] = -1
+	}
+
+	return dist
+}
+
+// bfs walks the graph from the given city and fills dist with the distance
+// of every visited city. It returns the visited cities in order, reusing the
+// given queue. The distances of unvisited cities must be -1, see newDistances
+// and reset.
+func (g *graph) bfs(from int, dist []int, queue []int) []int {
+	dist[from] = 0
+	queue = append(queue[:0], from)
+	for i := 0; i < len(queue); i++ {
+		current := queue[i]
+		for _, next := range g.adj[current] {
+			if next >= 0 && dist[next] < 0 {
+				dist[next] = dist[current] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+
+	return queue
+}
+
+// reset sets back the distances of the visited cities to -1.
+func (g *graph) reset(dist []int, visited []int) {
+	for _, i := range visited {
+		dist[i] = -1
+	}
+}
+
+// IterateBorders calls the given function once for every border of the map,
+// from its northern or western city, unless the neighbour doesn't declare the
+// border back. Cities are visited in the order of GetAll.
+func (cs Cities) IterateBorders(call func(city *City, dir Direction, neighbor *City)) {
+	for _, city := range cs.GetAll() {
+		city.IterateBorder(func(dir Direction, neighbor *City) {
+			if dir == North || dir == West {
+				if back, ok := neighbor.GetDirection(dir.Opposite()); ok && back == city {
+					return
+				}
+			}
+
+			call(city, dir, neighbor)
+		})
+	}
+}
+
+// Components returns the connected components of the map, the biggest first.
+// Cities of a component are sorted like GetAll, and components of the same
+// size are sorted by their first city.
+func (cs Cities) Components() [][]*City {
+	visited := make(map[*City]bool, len(cs))
+	components := [][]*City{}
+	for _, city := range cs.GetAll() {
+		if visited[city] {
+			continue
+		}
+
+		// Breadth first walk from the city
+		visited[city] = true
+		component := []*City{city}
+		for i := 0; i < len(component); i++ {
+			component[i].IterateBorder(func(_ Direction, neighbor *City) {
+				if !visited[neighbor] {
+					visited[neighbor] = true
+					component = append(component, neighbor)
+				}
+			})
+		}
+
+		sort.Slice(component, func(i, j int) bool {
+			return NaturalLess(component[i].Name, component[j].Name)
+		})
+		components = append(components, component)
+	}
+
+	sort.SliceStable(components, func(i, j int) bool {
+		return len(components[i]) > len(components[j])
+	})
+
+	return components
+}
+
+var (
+	ErrCityNotFound = fmt.Errorf("city not found")
+	ErrNoPath       = fmt.Errorf("no path between cities")
+)
+
+// Step is a move of a path: going toward Direction leads to City.
+type Step struct {
+	Direction Direction
+	City      *City
+}
+
+// Path is a sequence of steps starting from a city.
+type Path struct {
+	From  *City
+	Steps []Step
+}
+
+// Len returns the number of steps of the path.
+func (p Path) Len() int {
+	return len(p.Steps)
+}
+
+// To returns the last city of the path.
+func (p Path) To() *City {
+	if len(p.Steps) == 0 {
+		return p.From
+	}
+
+	return p.Steps[len(p.Steps)-1].City
+}
+
+func (p Path) String() string {
+	var b strings.Builder
+	b.WriteString(p.From.Name)
+	for _, step := range p.Steps {
+		fmt.Fprintf(&b, " -%s-> %s", step.Direction, step.City.Name)
+	}
+
+	return b.String()
+}
+
+func (p Path) MarshalJSON() ([]byte, error) {
+	type jsonStep struct {
+		Direction Direction `json:"direction"`
+		City      string    `json:"city"`
+	}
+
+	steps := make([]jsonStep, len(p.Steps))
+	for i, step := range p.Steps {
+		steps[i] = jsonStep{Direction: step.Direction, City: step.City.Name}
+	}
+
+	return json.Marshal(struct {
+		From   string     `json:"from"`
+		To     string     `json:"to"`
+		Length int        `json:"length"`
+		Steps  []jsonStep `json:"steps"`
+	}{p.From.Name, p.To().Name, p.Len(), steps})
+}
+
+// walk visits the cities reachable from the given one in breadth first order,
+// trying directions in the order of AllDirections, up to maxHops hops (no
+// limit if negative). It returns the visited cities, the first one being the
+// starting city, and the step used to reach each of them.
+func walk(from *City, maxHops int) (visited []*City, dist map[*City]int, reached map[*City]Step) {
+	visited = []*City{from}
+	dist = map[*City]int{from: 0}
+	reached = make(map[*City]Step)
+	for i := 0; i < len(visited); i++ {
+		current := visited[i]
+		if maxHops >= 0 && dist[current] >= maxHops {
+			continue
+		}
+
+		current.IterateBorder(func(dir Direction, neighbor *City) {
+			if _, ok := dist[neighbor]; ok {
+				return
+			}
+
+			dist[neighbor] = dist[current] + 1
+			reached[neighbor] = Step{Direction: dir, City: current}
+			visited = append(visited, neighbor)
+		})
+	}
+
+	return
+}
+
+func (cs Cities) lookup(name string) (*City, error) {
+	city, ok := cs.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("%w: `%s`", ErrCityNotFound, name)
+	}
+
+	return city, nil
+}
+
+// ShortestPath returns one of the shortest paths between two cities. When
+// several paths have the same length, directions are preferred in the order
+// of AllDirections.
+func (cs Cities) ShortestPath(from, to string) (Path, error) {
+	start, err := cs.lookup(from)
+	if err != nil {
+		return Path{}, err
+	}
+
+	end, err := cs.lookup(to)
+	if err != nil {
+		return Path{}, err
+	}
+
+	_, dist, reached := walk(start, -1)
+	length, ok := dist[end]
+	if !ok {
+		return Path{}, fmt.Errorf("%w: from `%s` to `%s`", ErrNoPath, from, to)
+	}
+
+	// Walk back from the end, `reached` holds the previous city of each step
+	path := Path{From: start, Steps: make([]Step, length)}
+	for city := end; city != start; {
+		step := reached[city]
+		length--
+		path.Steps[length] = Step{Direction: step.Direction, City: city}
+		city = step.City
+	}
+
+	return path, nil
+}
+
+// Reachable returns every city that can be reached from the given one, the
+// city itself excepted, sorted like GetAll.
+func (cs Cities) Reachable(from string) ([]*City, error) {
+	start, err := cs.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+
+	visited, _, _ := walk(start, -1)
+	reachable := visited[1:]
+	sort.Slice(reachable, func(i, j int) bool {
+		return NaturalLess(reachable[i].Name, reachable[j].Name)
+	})
+
+	return reachable, nil
+}
+
+// WithinHops returns the distance of every city that can be reached from the
+// given one in at most the given number of hops, including the city itself at
+// distance 0.
+func (cs Cities) WithinHops(from string, hops int) (map[*City]int, error) {
+	start, err := cs.lookup(from)
+	if err != nil {
+		return nil, err
+	}
+
+	if hops < 0 {
+		return nil, fmt.Errorf("number of hops cannot be negative: %d", hops)
+	}
+
+	_, dist, _ := walk(start, hops)
+	return dist, nil
+}
+
+// Crop returns a new map holding the cities within the given number of hops
+// of the center city, or its whole connected component if radius is negative.
+// Only the borders between two kept cities are kept, and they are copied as
+// they are: a one-sided border stays one-sided. City attributes are copied.
+func (cs Cities) Crop(center string, radius int) (Cities, error) {
+	start, err := cs.lookup(center)
+	if err != nil {
+		return nil, err
+	}
+
+	_, dist, _ := walk(start, radius)
+
+	cropped := NewCities()
+	for city := range dist {
+		copied := cropped.GetOrCreate(city.Name)
+		for key, value := range city.Attributes {
+			if copied.Attributes == nil {
+				copied.Attributes = make(map[string]string, len(city.Attributes))
+			}
+
+			copied.Attributes[key] = value
+		}
+	}
+
+	for city := range dist {
+		city.IterateBorder(func(dir Direction, neighbor *City) {
+			// SetDirection would border the neighbour back, copy the border
+			// from this side only
+			if _, ok := dist[neighbor]; ok {
+				cropped[city.Name].borderCities[dir] = cropped[neighbor.Name]
+			}
+		})
+	}
+
+	return cropped, nil
+}
@@ -0,0 +1,141 @@
+package invader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponents(t *testing.T) {
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a north=b\nb north=c\nd east=e\nf\n"))
+	require.NoError(t, err)
+
+	components := cities.Components()
+	require.Len(t, components, 3)
+
+	names := func(component []*City) (names []string) {
+		for _, city := range component {
+			names = append(names, city.Name)
+		}
+		return
+	}
+
+	require.Equal(t, []string{"a", "b", "c"}, names(components[0]))
+	require.Equal(t, []string{"d", "e"}, names(components[1]))
+	require.Equal(t, []string{"f"}, names(components[2]))
+}
+
+func TestShortestPath(t *testing.T) {
+	// a - b - c
+	// |       |
+	// d - e - f    g
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a east=b south=d\nb east=c\nc south=f\nd east=e\ne east=f\ng\n"))
+	require.NoError(t, err)
+
+	path, err := cities.ShortestPath("a", "f")
+	require.NoError(t, err)
+	require.Equal(t, 3, path.Len())
+	require.Equal(t, "f", path.To().Name)
+	// East is tried before South
+	require.Equal(t, "a -east-> b -east-> c -south-> f", path.String())
+
+	path, err = cities.ShortestPath("a", "a")
+	require.NoError(t, err)
+	require.Equal(t, 0, path.Len())
+	require.Equal(t, "a", path.To().Name)
+
+	_, err = cities.ShortestPath("a", "g")
+	require.ErrorIs(t, err, ErrNoPath)
+
+	_, err = cities.ShortestPath("a", "unknown")
+	require.ErrorIs(t, err, ErrCityNotFound)
+}
+
+func TestReachable(t *testing.T) {
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a north=b\nb north=c\nd east=e\n"))
+	require.NoError(t, err)
+
+	reachable, err := cities.Reachable("b")
+	require.NoError(t, err)
+	require.Len(t, reachable, 2)
+	require.Equal(t, "a", reachable[0].Name)
+	require.Equal(t, "c", reachable[1].Name)
+
+	_, err = cities.Reachable("unknown")
+	require.ErrorIs(t, err, ErrCityNotFound)
+}
+
+func TestWithinHops(t *testing.T) {
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a north=b\nb north=c\nc north=d\n"))
+	require.NoError(t, err)
+
+	dist, err := cities.WithinHops("a", 2)
+	require.NoError(t, err)
+
+	names := make(map[string]int)
+	for city, d := range dist {
+		names[city.Name] = d
+	}
+	require.Equal(t, map[string]int{"a": 0, "b": 1, "c": 2}, names)
+
+	_, err = cities.WithinHops("a", -1)
+	require.Error(t, err)
+}
+
+func TestCrop(t *testing.T) {
+	// a - b - c - d    e
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a east=b\nb east=c\nc east=d\ne\n"))
+	require.NoError(t, err)
+	cities["b"].Attributes = map[string]string{"country": "FR"}
+
+	cropped, err := cities.Crop("b", 1)
+	require.NoError(t, err)
+	require.Len(t, cropped, 3)
+	require.Equal(t, "FR", cropped["b"].Attributes["country"])
+
+	// The border toward `d` has been dropped
+	_, ok := cropped["c"].GetDirection(East)
+	require.False(t, ok)
+	west, ok := cropped["c"].GetDirection(West)
+	require.True(t, ok)
+	require.Same(t, cropped["b"], west)
+
+	// The original map is left untouched
+	east, ok := cities["c"].GetDirection(East)
+	require.True(t, ok)
+	require.Same(t, cities["d"], east)
+
+	component, err := cities.Crop("b", -1)
+	require.NoError(t, err)
+	require.Len(t, component, 4)
+
+	_, err = cities.Crop("unknown", 1)
+	require.ErrorIs(t, err, ErrCityNotFound)
+}
+
+func TestCropOneSided(t *testing.T) {
+	// `a` borders `b`, but `b` borders `c` back
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a north=b\nc north=b\n"))
+	require.NoError(t, err)
+
+	cropped, err := cities.Crop("a", -1)
+	require.NoError(t, err)
+	require.Len(t, cropped, 3)
+
+	north, ok := cropped["a"].GetDirection(North)
+	require.True(t, ok)
+	require.Same(t, cropped["b"], north)
+
+	// The border stays one-sided
+	south, ok := cropped["b"].GetDirection(South)
+	require.True(t, ok)
+	require.Same(t, cropped["c"], south)
+	require.Equal(t, cities.Fingerprint(), cropped.Fingerprint())
+}
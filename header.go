@@ -0,0 +1,121 @@
+package invader
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatVersion is the latest version of the map format understood by Parse.
+const FormatVersion = 1
+
+// headerPrefix starts every header line.
+const headerPrefix = "#!"
+
+// headerVersionKey is the key of the header line declaring the format version.
+const headerVersionKey = "version"
+
+// MapHeader holds the optional metadata declared at the top of a map, as
+// `#! key: value` lines placed before the first city:
+//
+//	#! version: 1
+//	#! name: Small map
+//	#! author: gfanton
+//	#! aliens: 4
+type MapHeader struct {
+	Name    string
+	Author  string
+	Version int // format version, 0 if not declared
+	Aliens  int // recommended number of aliens, 0 if not declared
+}
+
+// IsZero reports whether the header doesn't hold any metadata.
+func (h MapHeader) IsZero() bool {
+	return h == MapHeader{}
+}
+
+// Cropped returns the header of a map cropped down to the given number of
+// cities: the name of the original map is dropped, and the recommended number
+// of aliens is capped at the number of cities.
+func (h MapHeader) Cropped(cities int) MapHeader {
+	h.Name = ""
+	if h.Aliens > cities {
+		h.Aliens = cities
+	}
+
+	return h
+}
+
+// Print writes the header lines to the writer, skipping undeclared fields.
+func (h MapHeader) Print(w io.Writer) {
+	if h.Version > 0 {
+		fmt.Fprintf(w, "%s version: %d\n", headerPrefix, h.Version)
+	}
+	if h.Name != "" {
+		fmt.Fprintf(w, "%s name: %s\n", headerPrefix, h.Name)
+	}
+	if h.Author != "" {
+		fmt.Fprintf(w, "%s author: %s\n", headerPrefix, h.Author)
+	}
+	if h.Aliens > 0 {
+		fmt.Fprintf(w, "%s aliens: %d\n", headerPrefix, h.Aliens)
+	}
+}
+
+func isHeaderLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), headerPrefix)
+}
+
+// parseLine parses a single header line into the header. It returns the key
+// of the line, in lower case, even if its value is invalid.
+func (h *MapHeader) parseLine(line string, lineno int) (key string, perr *ParseError) {
+	offset := strings.Index(line, headerPrefix) + len(headerPrefix)
+	content := line[offset:]
+	column := utf8.RuneCountInString(line[:offset]) + 1
+	if trimmed := strings.TrimLeft(content, " \t"); trimmed != "" {
+		column += utf8.RuneCountInString(content) - utf8.RuneCountInString(trimmed)
+		content = trimmed
+	}
+
+	newError := func(kind ParseErrorKind, err error) *ParseError {
+		return &ParseError{Line: lineno, Column: column, Token: strings.TrimSpace(content), Kind: kind, Err: err}
+	}
+
+	key, value, ok := strings.Cut(content, ":")
+	if !ok {
+		return "", newError(KindInvalidHeader, nil)
+	}
+
+	key = strings.ToLower(strings.TrimSpace(key))
+	value = strings.TrimSpace(value)
+	switch key {
+	case "name":
+		h.Name = value
+	case "author":
+		h.Author = value
+	case headerVersionKey:
+		version, err := strconv.Atoi(value)
+		if err != nil || version <= 0 {
+			return key, newError(KindInvalidHeader, err)
+		}
+
+		if version > FormatVersion {
+			return key, newError(KindUnsupportedVersion, nil)
+		}
+
+		h.Version = version
+	case "aliens":
+		aliens, err := strconv.Atoi(value)
+		if err != nil || aliens < 0 {
+			return key, newError(KindInvalidHeader, err)
+		}
+
+		h.Aliens = aliens
+	default:
+		return key, newError(KindInvalidHeader, nil)
+	}
+
+	return key, nil
+}
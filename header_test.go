@@ -0,0 +1,111 @@
+package invader
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseHeader(t *testing.T) {
+	input := `# A hand made map
+#! version: 1
+#! name: Small map
+#! author: gfanton
+
+#! aliens: 3
+a north=b # the north city
+b south=a
+`
+	var header MapHeader
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader(input), WithHeader(&header))
+	require.NoError(t, err)
+	require.Len(t, cities, 2)
+
+	require.Equal(t, MapHeader{
+		Name:    "Small map",
+		Author:  "gfanton",
+		Version: 1,
+		Aliens:  3,
+	}, header)
+}
+
+func TestParseHeaderError(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		WantKind ParseErrorKind
+	}{
+		{
+			Name:     "unknown key",
+			Input:    "#! color: blue",
+			WantKind: KindInvalidHeader,
+		},
+		{
+			Name:     "missing value separator",
+			Input:    "#! name",
+			WantKind: KindInvalidHeader,
+		},
+		{
+			Name:     "invalid aliens count",
+			Input:    "#! aliens: many",
+			WantKind: KindInvalidHeader,
+		},
+		{
+			Name:     "unsupported version",
+			Input:    "#! version: 42",
+			WantKind: KindUnsupportedVersion,
+		},
+		{
+			Name:     "header after a city",
+			Input:    "a north=b\n#! name: late",
+			WantKind: KindMisplacedHeader,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cities := NewCities()
+			err := cities.Parse(strings.NewReader(tc.Input))
+
+			var perr *ParseError
+			require.True(t, errors.As(err, &perr))
+			require.Equal(t, tc.WantKind, perr.Kind)
+		})
+	}
+}
+
+func TestPrintHeaderRoundTrip(t *testing.T) {
+	header := MapHeader{Name: "Round trip", Version: FormatVersion, Aliens: 2}
+	cities := NewCities()
+	cities.GetOrCreate("a").SetDirection(North, cities.GetOrCreate("b"))
+
+	var buf bytes.Buffer
+	cities.Print(&buf, PrintHeader(header))
+
+	var parsedHeader MapHeader
+	parsed := NewCities()
+	err := parsed.Parse(&buf, WithHeader(&parsedHeader))
+	require.NoError(t, err)
+	require.Equal(t, header, parsedHeader)
+	require.Len(t, parsed, 2)
+}
+
+func TestCroppedHeader(t *testing.T) {
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a east=b\nb east=c\nc east=d\nd east=e\n"))
+	require.NoError(t, err)
+
+	cropped, err := cities.Crop("a", 1)
+	require.NoError(t, err)
+
+	header := MapHeader{Name: "Chain", Author: "gfanton", Version: FormatVersion, Aliens: 4}
+	require.Equal(t, MapHeader{Author: "gfanton", Version: FormatVersion, Aliens: 2}, header.Cropped(len(cropped)))
+
+	// A smaller recommendation is kept
+	header.Aliens = 1
+	require.Equal(t, 1, header.Cropped(len(cropped)).Aliens)
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"io"
 	"log"
-	"math/rand"
+	"time"
 )
 
 var (
@@ -16,21 +16,65 @@ type AlienInvaders struct {
 	writer io.Writer
 	logger *log.Logger
 	cities Cities
-	aliens map[*Alien]struct{} // Keeps track of all active aliens
+	header MapHeader
+	rand   *Rand
+	aliens []*Alien // Keeps track of all active aliens, in ID order
+	nextID uint
+
+	// iteration is the number of completed iterations
+	iteration int
+	observers []Observer
+
+	// Keep track of the simulation outcome
+	trapped   []*Alien
+	destroyed []DestroyedCity
 }
 
-func NewAlienInvaders(logger *log.Logger, writter io.Writer) *AlienInvaders {
-	return &AlienInvaders{
-		writer: writter,
-		logger: logger,
-		aliens: make(map[*Alien]struct{}),
-		cities: NewCities(),
+// InvaderOption configures the behaviour of NewAlienInvaders.
+type InvaderOption func(ai *AlienInvaders)
+
+// WithSeed seeds the random source of the simulation, so the same map, seed
+// and number of aliens always produce the same simulation. A seed derived from
+// the current time is used otherwise.
+func WithSeed(seed uint64) InvaderOption {
+	return func(ai *AlienInvaders) {
+		ai.rand = NewRand(seed)
+	}
+}
+
+// WithObserver registers an observer receiving the events of the simulation,
+// after the text observer writing to the output of the simulation.
+func WithObserver(o Observer) InvaderOption {
+	return func(ai *AlienInvaders) {
+		ai.observers = append(ai.observers, o)
 	}
 }
 
+// NewAlienInvaders returns a simulation writing its progress to writter, as
+// reported by a TextObserver, and logging its details to logger.
+func NewAlienInvaders(logger *log.Logger, writter io.Writer, opts ...InvaderOption) *AlienInvaders {
+	ai := &AlienInvaders{
+		writer:    writter,
+		logger:    logger,
+		cities:    NewCities(),
+		observers: []Observer{NewTextObserver(writter), logObserver{logger}},
+	}
+
+	for _, opt := range opts {
+		opt(ai)
+	}
+
+	if ai.rand == nil {
+		ai.rand = NewRand(uint64(time.Now().UnixNano()))
+	}
+
+	return ai
+}
+
 // ParseMap parses the map from the provided reader.
-func (ai *AlienInvaders) ParseMap(r io.Reader) error {
-	if err := ai.cities.Parse(r); err != nil {
+func (ai *AlienInvaders) ParseMap(r io.Reader, opts ...ParseOption) error {
+	opts = append([]ParseOption{WithHeader(&ai.header)}, opts...)
+	if err := ai.cities.Parse(r, opts...); err != nil {
 		return err
 	}
 
@@ -38,9 +82,37 @@ func (ai *AlienInvaders) ParseMap(r io.Reader) error {
 	return nil
 }
 
-// PrintMap prints the current map to stdout.
+// DecodeMap decodes the map, written in its JSON representation, from the
+// provided reader.
+func (ai *AlienInvaders) DecodeMap(r io.Reader, opts ...ParseOption) error {
+	opts = append([]ParseOption{WithHeader(&ai.header)}, opts...)
+	if err := ai.cities.DecodeJSON(r, opts...); err != nil {
+		return err
+	}
+
+	ai.logger.Printf("successfully decoded %d cities", len(ai.cities))
+	return nil
+}
+
+// Header returns the metadata declared by the parsed map, if any.
+func (ai *AlienInvaders) Header() MapHeader {
+	return ai.header
+}
+
+// Cities returns the current map.
+func (ai *AlienInvaders) Cities() Cities {
+	return ai.cities
+}
+
+// PrintMap prints the current map, along with its header, to stdout.
 func (ai *AlienInvaders) PrintMap() {
-	ai.cities.Print(ai.writer)
+	ai.cities.Print(ai.writer, PrintHeader(ai.header))
+}
+
+// EncodeMap writes the current map, along with its header, to stdout using the
+// JSON representation.
+func (ai *AlienInvaders) EncodeMap() error {
+	return ai.cities.EncodeJSON(ai.writer, PrintHeader(ai.header))
 }
 
 // GenerateAliens generates the given number of aliens and places them in random cities.
@@ -52,7 +124,7 @@ func (ai *AlienInvaders) GenerateAliens(x int) error {
 	case x == len(cities): // If the number of aliens equals the number of cities, there's no need to shuffle
 	default:
 		// Shuffle the cities and slice to the desired number of aliens
-		rand.Shuffle(len(cities), func(i, j int) {
+		ai.rand.Shuffle(len(cities), func(i, j int) {
 			cities[i], cities[j] = cities[j], cities[i]
 		})
 		cities = cities[:x]
@@ -60,19 +132,28 @@ func (ai *AlienInvaders) GenerateAliens(x int) error {
 
 	// Create new aliens in the chosen cities
 	for _, city := range cities {
-		alien := NewAlien(city)
-		ai.aliens[alien] = struct{}{}
+		ai.nextID++
+		ai.aliens = append(ai.aliens, NewAlien(ai.nextID, city))
+		ai.emit(AlienPlaced{Iteration: ai.iteration, Alien: ai.nextID, City: city.Name})
 	}
 
 	return nil
 }
 
-// nextIteration simulates the next iteration in the alien invasion.
+// emit delivers the event to every observer.
+func (ai *AlienInvaders) emit(e Event) {
+	for _, o := range ai.observers {
+		o.Observe(e)
+	}
+}
+
+// nextIteration simulates the next iteration in the alien invasion, moving
+// the aliens in ID order.
 // It returns the aliens that died during the iteration and an error, if any occurred.
 func (ai *AlienInvaders) nextIteration(ctx context.Context) (deadAliens []*Alien, err error) {
 	deadAliens = []*Alien{}
 
-	for alien := range ai.aliens {
+	for _, alien := range ai.aliens {
 		if ctx.Err() != nil {
 			return nil, ctx.Err() // If context is cancelled, return immediately
 		}
@@ -87,83 +168,161 @@ func (ai *AlienInvaders) nextIteration(ctx context.Context) (deadAliens []*Alien
 		currentCity := alien.CurrentCity
 
 		// Make a random move
-		occupyAlien, ok := alien.RandomMove()
+		occupyAlien, ok := alien.RandomMove(ai.rand)
 		if !ok { // Alien is trapped and cannot move
 			deadAliens = append(deadAliens, alien)
-			fmt.Fprintf(ai.writer, "alien %s has been trapped in `%s`!\n", alien.Name(), alien.CurrentCity.Name)
+			ai.emit(AlienTrapped{Iteration: ai.iteration, Alien: alien.ID, City: alien.CurrentCity.Name})
 			continue
 		}
 
-		ai.logger.Printf("alien `%s` moved from `%s` to `%s`", alien.Name(), currentCity.Name, alien.CurrentCity.Name)
+		ai.emit(AlienMoved{Iteration: ai.iteration, Alien: alien.ID, From: currentCity.Name, To: alien.CurrentCity.Name})
 
 		// Move was succefull, Check if the city is already occupied
 		if occupyAlien != nil {
-			ai.logger.Printf("city `%s` already occupied by alien `%s`", occupyAlien.CurrentCity.Name, occupyAlien.Name())
-
 			targetCity := alien.CurrentCity
 
 			// We got a fight !
+			ai.emit(FightOccurred{Iteration: ai.iteration, City: targetCity.Name, Attacker: alien.ID, Defender: occupyAlien.ID})
 
 			// Mark both aliens as dead
 			alien.Kill()
 			occupyAlien.Kill()
 
 			// Destroy the city
-			ai.cities.Destroy(targetCity.Name)
+			destroyed := ai.destroyCity(targetCity)
 
 			// Gather the dead aliens body for later cleanup
 			deadAliens = append(deadAliens, alien, occupyAlien)
 
-			fmt.Fprintf(ai.writer, "%s has been destroyed by alien %s and alien %s!\n", targetCity.Name, alien.Name(), occupyAlien.Name())
+			ai.emit(CityDestroyed{
+				Iteration: ai.iteration,
+				City:      destroyed.Name,
+				Borders:   destroyed.Borders,
+				Aliens:    [2]uint{alien.ID, occupyAlien.ID},
+			})
 		}
 	}
 
 	return
 }
 
-// Run starts the simulation and continues it for the specified number of
-// iterations or until context is cancelled.
-func (ai *AlienInvaders) Run(ctx context.Context, limit int) error {
-	totalAliens := len(ai.aliens)
-	trappedAliens := []*Alien{}
+// destroyCity removes the city from the map, keeping track of its borders.
+func (ai *AlienInvaders) destroyCity(city *City) DestroyedCity {
+	destroyed := DestroyedCity{Name: city.Name, Borders: make(map[Direction]string)}
+	city.IterateBorder(func(dir Direction, neighbor *City) {
+		destroyed.Borders[dir] = neighbor.Name
+	})
 
-	for steps := 0; steps < limit && ctx.Err() == nil; steps++ {
-		ai.logger.Printf("iteration: %d\n", steps)
+	ai.destroyed = append(ai.destroyed, destroyed)
+	ai.cities.Destroy(city.Name)
+	return destroyed
+}
 
-		// Generate next iteration, collect dead bodies
-		deadAliens, err := ai.nextIteration(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to generate next iteration: %w", err)
-		}
+// Outcome returns the current outcome of the simulation.
+func (ai *AlienInvaders) Outcome() Outcome {
+	return Outcome{
+		Destroyed: ai.destroyed,
+		Trapped:   ai.trapped,
+		Alive:     append([]*Alien{}, ai.aliens...),
+	}
+}
 
-		// Remove dead aliens from the map
-		for _, deadAlien := range deadAliens {
-			if deadAlien.State == Trapped {
-				// keep track of trapped aliens for later logging
-				trappedAliens = append(trappedAliens, deadAlien)
-			}
-			delete(ai.aliens, deadAlien)
-		}
+// Fingerprint returns the fingerprint of the current map, see
+// Cities.Fingerprint.
+func (ai *AlienInvaders) Fingerprint() string {
+	return ai.cities.Fingerprint()
+}
+
+// WriteDOT writes the current map as a GraphViz DOT graph, highlighting the
+// outcome of the simulation.
+func (ai *AlienInvaders) WriteDOT(w io.Writer) error {
+	return ai.cities.WriteDOT(w, WithOutcome(ai.Outcome()))
+}
 
-		if len(deadAliens) > 0 {
-			ai.logger.Printf("iteration[%d]: %d/%d aliens have been killed/trapped", steps, totalAliens-len(ai.aliens), totalAliens)
+// Iteration returns the number of completed iterations.
+func (ai *AlienInvaders) Iteration() int {
+	return ai.iteration
+}
+
+// Run starts the simulation and continues it until the specified number of
+// iterations has been completed or until context is cancelled.
+func (ai *AlienInvaders) Run(ctx context.Context, limit int) (err error) {
+	defer func() {
+		reason := EndStepLimit
+		switch {
+		case err == ErrAllAliensAreKO:
+			reason = EndAllAliensKO
+		case ctx.Err() != nil:
+			reason = EndCancelled
 		}
 
-		// If all aliens are dead, stop the simulation
-		if len(ai.aliens) == 0 {
-			return ErrAllAliensAreKO
+		ai.emit(SimulationEnded{
+			Iteration: ai.iteration,
+			Reason:    reason,
+			Alive:     len(ai.aliens),
+			Trapped:   len(ai.trapped),
+			Destroyed: len(ai.destroyed),
+		})
+	}()
+
+	// Nothing left to simulate
+	if len(ai.aliens) == 0 {
+		return ErrAllAliensAreKO
+	}
+
+	for ai.iteration < limit && ctx.Err() == nil {
+		if err := ai.step(ctx); err != nil {
+			return err
 		}
 	}
 
 	// Log the remaining alien and their position
-	ai.logger.Printf("%d/%d aliens left", len(ai.aliens)+len(trappedAliens), totalAliens)
-	for alien := range ai.aliens {
+	for _, alien := range ai.aliens {
 		ai.logger.Printf("alien `%s` live in `%s`", alien.Name(), alien.CurrentCity.Name)
 	}
-	for _, alien := range trappedAliens {
+	for _, alien := range ai.trapped {
 		ai.logger.Printf("alien `%s` trapped in `%s`", alien.Name(), alien.CurrentCity.Name)
 	}
 
 	// exit run
 	return ctx.Err()
 }
+
+// step simulates the next iteration and removes the dead aliens. It returns
+// ErrAllAliensAreKO once every alien has been killed or trapped.
+func (ai *AlienInvaders) step(ctx context.Context) error {
+	ai.iteration++
+
+	// Generate next iteration, collect dead bodies
+	deadAliens, err := ai.nextIteration(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to generate next iteration: %w", err)
+	}
+
+	// Keep track of trapped aliens for later logging
+	for _, deadAlien := range deadAliens {
+		if deadAlien.State == Trapped {
+			ai.trapped = append(ai.trapped, deadAlien)
+		}
+	}
+
+	// Remove dead aliens, keeping the others in ID order
+	if len(deadAliens) > 0 {
+		alive := ai.aliens[:0]
+		for _, alien := range ai.aliens {
+			if alien.State == Alive {
+				alive = append(alive, alien)
+			}
+		}
+		ai.aliens = alive
+	}
+
+	ai.emit(IterationCompleted{Iteration: ai.iteration, Alive: len(ai.aliens), Dead: len(deadAliens)})
+
+	// If all aliens are dead, stop the simulation
+	if len(ai.aliens) == 0 {
+		return ErrAllAliensAreKO
+	}
+
+	return nil
+}
@@ -1,6 +1,7 @@
 package invader
 
 import (
+	"bytes"
 	"context"
 	"io"
 	"log"
@@ -87,3 +88,74 @@ func TestAlienBattle(t *testing.T) {
 	require.Equal(t, ErrAllAliensAreKO, err)
 	require.Len(t, ai.aliens, 0)
 }
+
+func TestOutcome(t *testing.T) {
+	ai := NewAlienInvaders(testInvaderLogger, testInvaderDefaultWriter)
+	ctx := context.Background()
+
+	reader := strings.NewReader("a north=b")
+	err := ai.ParseMap(reader)
+	require.NoError(t, err)
+
+	err = ai.GenerateAliens(2)
+	require.NoError(t, err)
+
+	err = ai.Run(ctx, 100)
+	require.Equal(t, ErrAllAliensAreKO, err)
+
+	outcome := ai.Outcome()
+	require.Len(t, outcome.Destroyed, 1)
+	require.Len(t, outcome.Alive, 0)
+	require.Len(t, outcome.Trapped, 0)
+
+	// The destroyed city keeps track of its former border
+	destroyed := outcome.Destroyed[0]
+	_, ok := ai.cities.Get(destroyed.Name)
+	require.False(t, ok)
+	require.Len(t, destroyed.Borders, 1)
+}
+
+func TestSeededSimulation(t *testing.T) {
+	cities := NewCities()
+	err := GridGenerator{GridShape: GridShape{Width: 8, Height: 8}}.Generate(cities, NewRand(1))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	cities.Print(&buf)
+	input := buf.String()
+
+	run := func(seed uint64) (string, Outcome) {
+		var out bytes.Buffer
+		ai := NewAlienInvaders(testInvaderLogger, &out, WithSeed(seed))
+		err := ai.ParseMap(strings.NewReader(input))
+		require.NoError(t, err)
+
+		err = ai.GenerateAliens(20)
+		require.NoError(t, err)
+
+		// Alien IDs are local to the simulation
+		require.Equal(t, uint(1), ai.aliens[0].ID)
+
+		err = ai.Run(context.Background(), 1000)
+		if err != nil {
+			require.Equal(t, ErrAllAliensAreKO, err)
+		}
+
+		ai.PrintMap()
+		return out.String(), ai.Outcome()
+	}
+
+	output, outcome := run(42)
+	require.Contains(t, output, "has been destroyed")
+
+	for i := 0; i < 5; i++ {
+		again, againOutcome := run(42)
+		require.Equal(t, output, again)
+		require.Equal(t, outcome.Destroyed, againOutcome.Destroyed)
+		require.Equal(t, len(outcome.Alive), len(againOutcome.Alive))
+		for j, alien := range outcome.Alive {
+			require.Equal(t, alien.ID, againOutcome.Alive[j].ID)
+			require.Equal(t, alien.CurrentCity.Name, againOutcome.Alive[j].CurrentCity.Name)
+		}
+	}
+}
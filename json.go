@@ -0,0 +1,213 @@
+package invader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// jsonMap is the JSON representation of a map:
+//
+//	{
+//	  "version": 1,
+//	  "header": {"name": "Small map", "author": "gfanton", "aliens": 2},
+//	  "cities": [
+//	    {"name": "Paris", "borders": {"north": "Lille"}, "attributes": {"country": "FR"}},
+//	    {"name": "Lille", "borders": {"south": "Paris"}}
+//	  ]
+//	}
+//
+// Only "cities" and the city "name" are mandatory. Borders are declared from
+// at least one side, like in the text format.
+type jsonMap struct {
+	Version int         `json:"version"`
+	Header  *jsonHeader `json:"header,omitempty"`
+	Cities  []jsonCity  `json:"cities"`
+}
+
+type jsonHeader struct {
+	Name   string `json:"name,omitempty"`
+	Author string `json:"author,omitempty"`
+	Aliens int    `json:"aliens,omitempty"`
+}
+
+type jsonCity struct {
+	Name       string               `json:"name"`
+	Borders    map[Direction]string `json:"borders,omitempty"`
+	Attributes map[string]string    `json:"attributes,omitempty"`
+}
+
+// EncodeJSON writes the cities to the writer using the JSON representation.
+// The header given with PrintHeader, if any, is written as well.
+func (cs Cities) EncodeJSON(w io.Writer, opts ...PrintOption) error {
+	var cfg printConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	m := cs.jsonMap(cfg.header)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&m)
+}
+
+// jsonMap returns the JSON representation of the cities, along with the given
+// header.
+func (cs Cities) jsonMap(h MapHeader) jsonMap {
+	m := jsonMap{
+		Version: FormatVersion,
+		Cities:  make([]jsonCity, 0, len(cs)),
+	}
+
+	if h.Name != "" || h.Author != "" || h.Aliens > 0 {
+		m.Header = &jsonHeader{Name: h.Name, Author: h.Author, Aliens: h.Aliens}
+	}
+
+	for _, city := range cs.GetAll() {
+		jc := jsonCity{Name: city.Name, Attributes: city.Attributes}
+		city.IterateBorder(func(dir Direction, neighbor *City) {
+			if jc.Borders == nil {
+				jc.Borders = make(map[Direction]string)
+			}
+
+			jc.Borders[dir] = neighbor.Name
+		})
+
+		m.Cities = append(m.Cities, jc)
+	}
+
+	return m
+}
+
+// DecodeJSON reads cities written in the JSON representation and populates
+// the Cities map. Options are supported like with Parse, the position of a
+// city in the "cities" array, starting at 1, being reported as its line.
+func (cs Cities) DecodeJSON(r io.Reader, opts ...ParseOption) error {
+	var cfg parseConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var m jsonMap
+	if err := json.NewDecoder(r).Decode(&m); err != nil {
+		if cfg.filename != "" {
+			return fmt.Errorf("unable to decode json map `%s`: %w", cfg.filename, err)
+		}
+
+		return fmt.Errorf("unable to decode json map: %w", err)
+	}
+
+	return cs.decodeJSONMap(m, cfg)
+}
+
+// declarations checks the city, found at the given position of the "cities"
+// array, and returns its normalized name along with its borders.
+func (jc jsonCity) declarations(pos int) (string, []BorderDeclaration, *ParseError) {
+	name := NormalizeName(jc.Name)
+	if name == "" {
+		return "", nil, &ParseError{Line: pos, Kind: KindEmptyName}
+	}
+
+	borders := make(map[Direction]string, len(jc.Borders))
+	for key, neighbor := range jc.Borders {
+		dir, err := ParseDirection(string(key))
+		if err != nil {
+			return "", nil, &ParseError{Line: pos, Token: string(key), Kind: KindInvalidDirection, Err: err}
+		}
+
+		switch neighbor = NormalizeName(neighbor); neighbor {
+		case "":
+			return "", nil, &ParseError{Line: pos, Token: string(key) + "=", Kind: KindEmptyName}
+		case name:
+			return "", nil, &ParseError{Line: pos, Token: string(key) + "=" + neighbor, Kind: KindSelfBorder}
+		}
+
+		borders[dir] = neighbor
+	}
+
+	var decls []BorderDeclaration
+	for _, dir := range AllDirections {
+		if neighbor, ok := borders[dir]; ok {
+			decls = append(decls, BorderDeclaration{Line: pos, City: name, Direction: dir, Neighbor: neighbor})
+		}
+	}
+
+	return name, decls, nil
+}
+
+// decodeJSONMap populates the cities from their JSON representation.
+func (cs Cities) decodeJSONMap(m jsonMap, cfg parseConfig) error {
+	if m.Version > FormatVersion {
+		return &ParseError{File: cfg.filename, Token: strconv.Itoa(m.Version), Kind: KindUnsupportedVersion}
+	}
+
+	if cfg.header != nil {
+		*cfg.header = MapHeader{Version: m.Version}
+		if h := m.Header; h != nil {
+			cfg.header.Name, cfg.header.Author, cfg.header.Aliens = h.Name, h.Author, h.Aliens
+		}
+	}
+
+	// Check every city before touching the map, invalid cities are skipped
+	// when every error is reported
+	var errs ParseErrors
+	names := make([]string, len(m.Cities))
+	decls := make([][]BorderDeclaration, len(m.Cities))
+	for i, jc := range m.Cities {
+		name, borders, perr := jc.declarations(i + 1)
+		if perr != nil {
+			perr.File = cfg.filename
+			if !cfg.allErrors {
+				return perr
+			}
+
+			errs = append(errs, perr)
+			continue
+		}
+
+		names[i], decls[i] = name, borders
+	}
+
+	if cfg.strict {
+		// Like Parse, the map is left untouched if it cannot be read
+		if len(errs) > 0 {
+			return errs.err()
+		}
+
+		validator := newBorderValidator()
+		for i := range m.Cities {
+			validator.add(i+1, names[i], decls[i])
+		}
+
+		if err := validator.apply(cs); err != nil {
+			return err
+		}
+	}
+
+	for i, jc := range m.Cities {
+		if names[i] == "" {
+			continue
+		}
+
+		city := cs.GetOrCreate(names[i])
+		for key, value := range jc.Attributes {
+			if city.Attributes == nil {
+				city.Attributes = make(map[string]string)
+			}
+
+			city.Attributes[key] = value
+		}
+
+		// Borders have already been applied by the validator in strict mode
+		if cfg.strict {
+			continue
+		}
+
+		for _, decl := range decls[i] {
+			city.SetDirection(decl.Direction, cs.GetOrCreate(decl.Neighbor))
+		}
+	}
+
+	return errs.err()
+}
@@ -0,0 +1,114 @@
+package invader
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncodeDecodeJSON(t *testing.T) {
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a north=b east=\"New York\"\nb south=a\n"))
+	require.NoError(t, err)
+	cities["a"].Attributes = map[string]string{"country": "FR"}
+
+	header := MapHeader{Name: "json", Aliens: 2}
+
+	var buf bytes.Buffer
+	err = cities.EncodeJSON(&buf, PrintHeader(header))
+	require.NoError(t, err)
+
+	var decodedHeader MapHeader
+	decoded := NewCities()
+	err = decoded.DecodeJSON(&buf, WithHeader(&decodedHeader), WithStrict())
+	require.NoError(t, err)
+	require.Len(t, decoded, 3)
+	require.Equal(t, MapHeader{Name: "json", Version: FormatVersion, Aliens: 2}, decodedHeader)
+
+	a, ok := decoded.Get("a")
+	require.True(t, ok)
+	require.Equal(t, map[string]string{"country": "FR"}, a.Attributes)
+
+	east, ok := a.GetDirection(East)
+	require.True(t, ok)
+	require.Equal(t, "New York", east.Name)
+
+	back, ok := east.GetDirection(West)
+	require.True(t, ok)
+	require.Equal(t, a, back)
+}
+
+func TestDecodeJSONError(t *testing.T) {
+	testCases := []struct {
+		Name  string
+		Input string
+	}{
+		{Name: "invalid json", Input: `{"cities": [`},
+		{Name: "unsupported version", Input: `{"version": 42, "cities": []}`},
+		{Name: "empty name", Input: `{"cities": [{"name": ""}]}`},
+		{Name: "invalid direction", Input: `{"cities": [{"name": "a", "borders": {"up": "b"}}]}`},
+		{Name: "self border", Input: `{"cities": [{"name": "a", "borders": {"north": "a"}}]}`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cities := NewCities()
+			err := cities.DecodeJSON(strings.NewReader(tc.Input))
+			require.Error(t, err)
+			require.Len(t, cities, 0)
+		})
+	}
+}
+
+func TestDecodeJSONStrict(t *testing.T) {
+	input := `{"cities": [
+		{"name": "a", "borders": {"north": "b"}},
+		{"name": "b", "borders": {"south": "c"}}
+	]}`
+
+	cities := NewCities()
+	err := cities.DecodeJSON(strings.NewReader(input), WithStrict())
+
+	var cerr *ConflictError
+	require.True(t, errors.As(err, &cerr))
+	require.Equal(t, ConflictOverwrite, cerr.Conflicts[len(cerr.Conflicts)-1].Kind)
+	require.Equal(t, 2, cerr.Conflicts[len(cerr.Conflicts)-1].Border.Line)
+	require.Len(t, cities, 0)
+}
+
+func TestDecodeJSONAllErrors(t *testing.T) {
+	input := `{"cities": [
+		{"name": "a", "borders": {"north": "b"}},
+		{"name": "c", "borders": {"up": "a"}},
+		{"name": "b", "borders": {"south": "a"}},
+		{"name": ""}
+	]}`
+
+	cities := NewCities()
+	err := cities.DecodeJSON(strings.NewReader(input), WithAllErrors(), WithFileName("map.json"))
+
+	var errs ParseErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 2)
+	require.Equal(t, 2, errs[0].Line)
+	require.Equal(t, KindInvalidDirection, errs[0].Kind)
+	require.Equal(t, 4, errs[1].Line)
+	require.Equal(t, KindEmptyName, errs[1].Kind)
+	require.Contains(t, err.Error(), "map.json:2: invalid direction `up`")
+
+	// Valid cities are still decoded
+	require.Len(t, cities, 2)
+
+	// Without WithAllErrors, decoding stops at the first invalid city
+	cities = NewCities()
+	err = cities.DecodeJSON(strings.NewReader(input), WithFileName("map.json"))
+
+	var perr *ParseError
+	require.True(t, errors.As(err, &perr))
+	require.Equal(t, "map.json", perr.File)
+	require.Equal(t, 2, perr.Line)
+	require.Len(t, cities, 0)
+}
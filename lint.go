@@ -0,0 +1,253 @@
+package invader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Severity tells whether a lint issue should block a map.
+type Severity int
+
+const (
+	SeverityWarning Severity = iota // the map can be used but looks suspicious
+	SeverityError                   // the map is invalid or inconsistent
+)
+
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	}
+
+	return fmt.Sprintf("Severity(%d)", int(s))
+}
+
+func (s Severity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// Rules checked by Lint.
+const (
+	LintRuleSyntax       = "syntax"         // the line cannot be parsed
+	LintRuleHeader       = "header"         // the header is malformed or misplaced
+	LintRuleVersion      = "version"        // the format version is missing or unsupported
+	LintRuleConflict     = "conflict"       // borders contradict each other
+	LintRuleAsymmetric   = "asymmetric"     // a border is not declared back
+	LintRuleUndeclared   = "undeclared"     // a city is referenced but has no line of its own
+	LintRuleIsolated     = "isolated"       // a city has no border
+	LintRuleDisconnected = "disconnected"   // some cities cannot be reached from the main component
+	LintRuleDuplicate    = "duplicate-line" // a line is declared twice
+	LintRuleName         = "name"           // a city name breaks the naming convention
+	LintRuleGeometry     = "geometry"       // borders cannot be drawn on a grid
+)
+
+// LintIssue is a problem found in a map.
+type LintIssue struct {
+	Severity Severity `json:"severity"`
+	Rule     string   `json:"rule"`
+	Line     int      `json:"line,omitempty"` // 0 if the issue is not bound to a line
+	City     string   `json:"city,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// LintReport holds every issue found in a map, sorted by line.
+type LintReport struct {
+	File   string      `json:"file,omitempty"`
+	Issues []LintIssue `json:"issues"`
+}
+
+// Count returns the number of issues of the given severity.
+func (r *LintReport) Count(severity Severity) (n int) {
+	for _, issue := range r.Issues {
+		if issue.Severity == severity {
+			n++
+		}
+	}
+
+	return
+}
+
+// Print writes the report in a human-readable form, one issue per line.
+func (r *LintReport) Print(w io.Writer) {
+	for _, issue := range r.Issues {
+		pos := r.File
+		if issue.Line > 0 {
+			pos = fmt.Sprintf("%s:%d", pos, issue.Line)
+		}
+
+		if pos != "" {
+			pos += ": "
+		}
+
+		fmt.Fprintf(w, "%s%s: %s [%s]\n", pos, issue.Severity, issue.Message, issue.Rule)
+	}
+
+	fmt.Fprintf(w, "%d error(s), %d warning(s)\n", r.Count(SeverityError), r.Count(SeverityWarning))
+}
+
+// EncodeJSON writes the report as a JSON object.
+func (r *LintReport) EncodeJSON(w io.Writer) error {
+	report := struct {
+		*LintReport
+		Errors   int `json:"errors"`
+		Warnings int `json:"warnings"`
+	}{r, r.Count(SeverityError), r.Count(SeverityWarning)}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&report)
+}
+
+// LintConfig configures Lint.
+type LintConfig struct {
+	// FileName is the name of the linted file, reported by the issues.
+	FileName string
+	// NamePattern, if set, must match every city name.
+	NamePattern *regexp.Regexp
+}
+
+// Lint reads a map written in the text format and reports its problems
+// without stopping at the first one. An error is only returned if the map
+// cannot be read.
+func Lint(r io.Reader, cfg LintConfig) (*LintReport, error) {
+	report := &LintReport{File: cfg.FileName, Issues: []LintIssue{}}
+	addIssue := func(severity Severity, rule string, line int, city string, format string, args ...interface{}) {
+		report.Issues = append(report.Issues, LintIssue{
+			Severity: severity,
+			Rule:     rule,
+			Line:     line,
+			City:     city,
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	var header MapHeader
+	validator := newBorderValidator()
+	firstRefs := make(map[string]int) // line on which a city is referenced first
+	lines := make(map[string]int)     // canonical form of the city lines
+	pcfg := parseConfig{allErrors: true, header: &header}
+	err := scanMap(r, &pcfg, func(lineno int, cityName string, borders []BorderDeclaration) {
+		key := make([]string, len(borders)+1)
+		key[0] = cityName
+		for i, border := range borders {
+			key[i+1] = string(border.Direction) + "=" + border.Neighbor
+			if _, ok := firstRefs[border.Neighbor]; !ok {
+				firstRefs[border.Neighbor] = lineno
+			}
+		}
+
+		sort.Strings(key[1:])
+		canonical := strings.Join(key, "\x00")
+		if line, ok := lines[canonical]; ok {
+			addIssue(SeverityWarning, LintRuleDuplicate, lineno, cityName, "duplicate of line %d", line)
+		} else {
+			lines[canonical] = lineno
+		}
+
+		validator.add(lineno, cityName, borders)
+	})
+
+	var perrs ParseErrors
+	var perr *ParseError
+	switch {
+	case err == nil:
+	case errors.As(err, &perrs):
+	case errors.As(err, &perr):
+		perrs = ParseErrors{perr}
+	default:
+		return nil, err
+	}
+
+	for _, perr := range perrs {
+		rule := LintRuleSyntax
+		switch perr.Kind {
+		case KindInvalidHeader, KindMisplacedHeader:
+			rule = LintRuleHeader
+		case KindUnsupportedVersion:
+			rule = LintRuleVersion
+		}
+
+		addIssue(SeverityError, rule, perr.Line, "", "%s `%s` at column %d", perr.Kind, perr.Token, perr.Column)
+	}
+
+	// An invalid or unsupported version has already been reported by the
+	// parser, only warn when the map doesn't declare any version
+	if !pcfg.versioned {
+		addIssue(SeverityWarning, LintRuleVersion, 0, "", "missing format version, add `%s version: %d` to the header", headerPrefix, FormatVersion)
+	}
+
+	for _, conflict := range validator.finish() {
+		rule := LintRuleConflict
+		if conflict.Kind == ConflictOneSided {
+			rule = LintRuleAsymmetric
+		}
+
+		addIssue(SeverityError, rule, conflict.Border.Line, conflict.Border.City, "%s", conflict.Message())
+	}
+
+	// Check the graph built from the valid borders
+	cities := NewCities()
+	validator.populate(cities)
+
+	lineOf := func(name string) int {
+		if line, ok := validator.heads[name]; ok {
+			return line
+		}
+
+		return firstRefs[name]
+	}
+
+	lowerNames := make(map[string]string)
+	for _, city := range cities.GetAll() {
+		if _, ok := validator.heads[city.Name]; !ok {
+			addIssue(SeverityWarning, LintRuleUndeclared, lineOf(city.Name), city.Name, "city `%s` is referenced but never declared on its own line", city.Name)
+		}
+
+		if len(city.GetAvailableDirections()) == 0 {
+			addIssue(SeverityWarning, LintRuleIsolated, lineOf(city.Name), city.Name, "city `%s` has no border", city.Name)
+		}
+
+		if cfg.NamePattern != nil && !cfg.NamePattern.MatchString(city.Name) {
+			addIssue(SeverityWarning, LintRuleName, lineOf(city.Name), city.Name, "city name `%s` doesn't match `%s`", city.Name, cfg.NamePattern)
+		}
+
+		lower := strings.ToLower(city.Name)
+		if other, ok := lowerNames[lower]; ok {
+			addIssue(SeverityWarning, LintRuleName, lineOf(city.Name), city.Name, "city name `%s` only differs by case from `%s`", city.Name, other)
+		} else {
+			lowerNames[lower] = city.Name
+		}
+	}
+
+	if components := cities.Components(); len(components) > 1 {
+		main := components[0]
+		for _, component := range components[1:] {
+			// Isolated cities have already been reported
+			if len(component) == 1 && len(component[0].GetAvailableDirections()) == 0 {
+				continue
+			}
+
+			first := component[0]
+			addIssue(SeverityWarning, LintRuleDisconnected, lineOf(first.Name), first.Name,
+				"city `%s` and %d other(s) are disconnected from the main component of %d cities (`%s`)",
+				first.Name, len(component)-1, len(main), main[0].Name)
+		}
+	}
+
+	for _, conflict := range cities.Layout().Conflicts {
+		addIssue(SeverityWarning, LintRuleGeometry, lineOf(conflict.City.Name), conflict.City.Name, "%s", conflict)
+	}
+
+	sort.SliceStable(report.Issues, func(i, j int) bool {
+		return report.Issues[i].Line < report.Issues[j].Line
+	})
+
+	return report, nil
+}
@@ -0,0 +1,147 @@
+package invader
+
+import (
+	"bytes"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Input     string
+		Config    LintConfig
+		WantRules []string
+	}{
+		{
+			Name:  "valid map",
+			Input: "#! version: 1\na north=b\nb south=a\n",
+		},
+		{
+			Name:      "missing version",
+			Input:     "a north=b\nb south=a\n",
+			WantRules: []string{LintRuleVersion},
+		},
+		{
+			Name:      "unsupported version",
+			Input:     "#! version: 99\na north=b\nb south=a\n",
+			WantRules: []string{LintRuleVersion},
+		},
+		{
+			Name:  "upper case version",
+			Input: "#! VERSION: 1\na north=b\nb south=a\n",
+		},
+		{
+			Name:      "invalid version",
+			Input:     "#! version: one\na north=b\nb south=a\n",
+			WantRules: []string{LintRuleHeader},
+		},
+		{
+			Name:      "syntax error",
+			Input:     "#! version: 1\na north=b\nb south=a\nc nort=a\n",
+			WantRules: []string{LintRuleSyntax},
+		},
+		{
+			Name:      "conflicting border",
+			Input:     "#! version: 1\na north=b\na north=c\nb south=a\n",
+			WantRules: []string{LintRuleConflict, LintRuleUndeclared, LintRuleIsolated},
+		},
+		{
+			Name:      "asymmetric border",
+			Input:     "#! version: 1\na north=b\nb\n",
+			WantRules: []string{LintRuleAsymmetric},
+		},
+		{
+			Name:      "undeclared city",
+			Input:     "#! version: 1\na north=b\n",
+			WantRules: []string{LintRuleUndeclared},
+		},
+		{
+			Name:      "isolated city",
+			Input:     "#! version: 1\na north=b\nb south=a\nc\n",
+			WantRules: []string{LintRuleIsolated},
+		},
+		{
+			Name:      "disconnected cities",
+			Input:     "#! version: 1\na north=b\nb south=a north=c\nc south=b\nd east=e\ne west=d\n",
+			WantRules: []string{LintRuleDisconnected},
+		},
+		{
+			Name:      "duplicate line",
+			Input:     "#! version: 1\na north=b east=c\nb south=a\nc west=a\na east=c north=b\n",
+			WantRules: []string{LintRuleDuplicate},
+		},
+		{
+			Name:      "name pattern",
+			Input:     "#! version: 1\ncity_1 north=city_2\ncity_2 south=city_1 north=Paris\nParis south=city_2\n",
+			Config:    LintConfig{NamePattern: regexp.MustCompile(`^city_\d+$`)},
+			WantRules: []string{LintRuleName},
+		},
+		{
+			Name:      "names differing by case",
+			Input:     "#! version: 1\nparis north=Paris\nParis south=paris\n",
+			WantRules: []string{LintRuleName},
+		},
+		{
+			Name:      "impossible geometry",
+			Input:     "#! version: 1\na north=b west=d\nb south=a east=c\nc west=b south=d\nd north=c east=a\n",
+			WantRules: []string{LintRuleGeometry},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			report, err := Lint(strings.NewReader(tc.Input), tc.Config)
+			require.NoError(t, err)
+
+			rules := []string{}
+			for _, issue := range report.Issues {
+				rules = append(rules, issue.Rule)
+			}
+
+			if len(tc.WantRules) == 0 {
+				require.Empty(t, rules)
+				return
+			}
+
+			require.Equal(t, tc.WantRules, rules)
+		})
+	}
+}
+
+func TestLintReport(t *testing.T) {
+	input := "#! version: 1\na north=b\na north=c\nb south=a\nc south=a\n"
+	report, err := Lint(strings.NewReader(input), LintConfig{FileName: "test.map"})
+	require.NoError(t, err)
+	require.Equal(t, 2, report.Count(SeverityError))
+
+	var buf bytes.Buffer
+	report.Print(&buf)
+	require.Contains(t, buf.String(), "test.map:3: error: `a` north=`c` overwrites `a` north=`b` declared on line 2 [conflict]\n")
+	require.Contains(t, buf.String(), "2 error(s), 1 warning(s)\n")
+
+	buf.Reset()
+	err = report.EncodeJSON(&buf)
+	require.NoError(t, err)
+
+	var decoded struct {
+		File   string `json:"file"`
+		Errors int    `json:"errors"`
+		Issues []struct {
+			Severity string `json:"severity"`
+			Rule     string `json:"rule"`
+			Line     int    `json:"line"`
+		} `json:"issues"`
+	}
+	err = json.Unmarshal(buf.Bytes(), &decoded)
+	require.NoError(t, err)
+	require.Equal(t, "test.map", decoded.File)
+	require.Equal(t, 2, decoded.Errors)
+	require.Equal(t, "error", decoded.Issues[0].Severity)
+	require.Equal(t, LintRuleConflict, decoded.Issues[0].Rule)
+	require.Equal(t, 3, decoded.Issues[0].Line)
+}
@@ -0,0 +1,102 @@
+package invader
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalizeName returns the Unicode normalized form (NFC) of a city name, so
+// visually identical names refer to the same city.
+func NormalizeName(name string) string {
+	return norm.NFC.String(name)
+}
+
+// needsQuote reports whether the name must be quoted to be read back by Parse.
+func needsQuote(name string) bool {
+	if name == "" || strings.HasPrefix(name, "#") {
+		return true
+	}
+
+	return strings.IndexFunc(name, func(r rune) bool {
+		return unicode.IsSpace(r) || r == '=' || r == '"'
+	}) >= 0
+}
+
+// quoteName returns the name as written in a map, surrounded by double quotes
+// and escaped if it contains whitespaces or reserved characters.
+func quoteName(name string) string {
+	if !needsQuote(name) {
+		return name
+	}
+
+	var b strings.Builder
+	b.Grow(len(name) + 2)
+	b.WriteByte('"')
+	for _, r := range name {
+		switch r {
+		case '"', '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case '\n':
+			b.WriteString(`\n`)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+
+	return b.String()
+}
+
+// NaturalLess reports whether name a sorts before name b in natural order:
+// digit sequences are compared by their numeric value, so `city_2` sorts
+// before `city_10`.
+func NaturalLess(a, b string) bool {
+	for a != "" && b != "" {
+		da, db := isDigit(a[0]), isDigit(b[0])
+		switch {
+		case da && db:
+			var na, nb string
+			na, a = splitDigits(a)
+			nb, b = splitDigits(b)
+
+			// Compare numeric values, ignoring leading zeros
+			ta, tb := strings.TrimLeft(na, "0"), strings.TrimLeft(nb, "0")
+			if len(ta) != len(tb) {
+				return len(ta) < len(tb)
+			}
+			if ta != tb {
+				return ta < tb
+			}
+			if na != nb { // same value, fewer leading zeros first
+				return len(na) < len(nb)
+			}
+		case da != db:
+			return da
+		default:
+			if a[0] != b[0] {
+				return a[0] < b[0]
+			}
+
+			a, b = a[1:], b[1:]
+		}
+	}
+
+	return len(a) < len(b)
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
+
+// splitDigits splits s after its leading digits.
+func splitDigits(s string) (digits, rest string) {
+	i := 0
+	for i < len(s) && isDigit(s[i]) {
+		i++
+	}
+
+	return s[:i], s[i:]
+}
@@ -0,0 +1,112 @@
+package invader
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuoteName(t *testing.T) {
+	testCases := []struct {
+		Name string
+		Want string
+	}{
+		{Name: "Paris", Want: "Paris"},
+		{Name: "Zürich", Want: "Zürich"},
+		{Name: "city#1", Want: "city#1"},
+		{Name: "New York", Want: `"New York"`},
+		{Name: "Saint-Étienne=Nord", Want: `"Saint-Étienne=Nord"`},
+		{Name: "#hashtag", Want: `"#hashtag"`},
+		{Name: `The "Big" \ Apple`, Want: `"The \"Big\" \\ Apple"`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			require.Equal(t, tc.Want, quoteName(tc.Name))
+		})
+	}
+}
+
+func TestParseQuotedNames(t *testing.T) {
+	input := `"New York" south="Saint-Étienne=Nord" east="The \"Big\" \\ Apple"` + "\n" +
+		// "Saint-Étienne" with a combining accent must map to the same city
+		"\"Saint-E\u0301tienne=Nord\" north=\"New York\"\n"
+
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader(input), WithStrict())
+	require.NoError(t, err)
+	require.Len(t, cities, 3)
+
+	newYork, ok := cities.Get("New York")
+	require.True(t, ok)
+
+	south, ok := newYork.GetDirection(South)
+	require.True(t, ok)
+	require.Equal(t, "Saint-Étienne=Nord", south.Name)
+
+	east, ok := newYork.GetDirection(East)
+	require.True(t, ok)
+	require.Equal(t, `The "Big" \ Apple`, east.Name)
+
+	// Printed names must be read back identically
+	var buf bytes.Buffer
+	cities.Print(&buf)
+
+	parsed := NewCities()
+	err = parsed.Parse(&buf)
+	require.NoError(t, err)
+	for name := range cities {
+		_, ok := parsed.Get(name)
+		require.True(t, ok, "city `%s` has not been read back", name)
+	}
+}
+
+func TestParseQuotedNamesError(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Input    string
+		WantKind ParseErrorKind
+	}{
+		{Name: "unterminated quote", Input: `"New York north=a`, WantKind: KindInvalidQuote},
+		{Name: "empty quoted name", Input: `a north=""`, WantKind: KindInvalidQuote},
+		{Name: "unknown escape", Input: `"a\b" north=c`, WantKind: KindInvalidQuote},
+		{Name: "trailing characters", Input: `"a"b north=c`, WantKind: KindReservedCharacter},
+		{Name: "trailing border characters", Input: `a north="b"c`, WantKind: KindMalformedBorder},
+		{Name: "double equal", Input: `a north=b=c`, WantKind: KindMalformedBorder},
+		{Name: "quoted self border", Input: `"a b" north="a b"`, WantKind: KindSelfBorder},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cities := NewCities()
+			err := cities.Parse(strings.NewReader(tc.Input))
+
+			var perr *ParseError
+			require.ErrorAs(t, err, &perr)
+			require.Equal(t, tc.WantKind, perr.Kind)
+		})
+	}
+}
+
+func TestNaturalLess(t *testing.T) {
+	testCases := []struct {
+		A, B string
+		Want bool
+	}{
+		{A: "city_2", B: "city_10", Want: true},
+		{A: "city_10", B: "city_2", Want: false},
+		{A: "city_10", B: "city_10", Want: false},
+		{A: "city_1", B: "city_01", Want: true},
+		{A: "city", B: "city_1", Want: true},
+		{A: "Lille", B: "Paris", Want: true},
+		{A: "a9b", B: "a10a", Want: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.A+"<"+tc.B, func(t *testing.T) {
+			require.Equal(t, tc.Want, NaturalLess(tc.A, tc.B))
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package invader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// NameSource draws candidate names for generated cities.
+type NameSource interface {
+	Next(r *Rand) string
+}
+
+// syllables makes up place names from random syllables.
+type syllables struct{}
+
+var (
+	syllableOnsets = []string{"b", "br", "c", "ch", "d", "f", "g", "gr", "k", "l", "m", "n", "p", "r", "s", "st", "t", "tr", "v", "z"}
+	syllableVowels = []string{"a", "e", "i", "o", "u", "ai", "ou", "ea"}
+	syllableEnds   = []string{"", "", "", "", "n", "r", "s", "l", "ville", "burg", "ford", "ton", "mont"}
+)
+
+// Syllables returns a source of made-up place names such as `Gralimont`.
+func Syllables() NameSource {
+	return syllables{}
+}
+
+func (syllables) Next(r *Rand) string {
+	var b strings.Builder
+	for n := 2 + r.Intn(2); n > 0; n-- {
+		b.WriteString(syllableOnsets[r.Intn(len(syllableOnsets))])
+		b.WriteString(syllableVowels[r.Intn(len(syllableVowels))])
+	}
+	b.WriteString(syllableEnds[r.Intn(len(syllableEnds))])
+
+	name := b.String()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// wordList draws the words of a list in a random order, shuffling the list
+// again once every word has been drawn.
+type wordList struct {
+	words []string
+	next  int
+}
+
+// WordList returns a source drawing names from the given words.
+func WordList(words []string) NameSource {
+	return &wordList{words: append([]string(nil), words...)}
+}
+
+func (l *wordList) Next(r *Rand) string {
+	if l.next == 0 {
+		r.Shuffle(len(l.words), func(i, j int) { l.words[i], l.words[j] = l.words[j], l.words[i] })
+	}
+
+	word := l.words[l.next]
+	l.next = (l.next + 1) % len(l.words)
+	return word
+}
+
+// ReadWordList reads a list of names, one per line. Blank lines and lines
+// starting with `#` are ignored.
+func ReadWordList(r io.Reader) (NameSource, error) {
+	var words []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		word := NormalizeName(strings.TrimSpace(scanner.Text()))
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+
+		words = append(words, word)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read word list: %w", err)
+	}
+
+	if len(words) == 0 {
+		return nil, fmt.Errorf("empty word list")
+	}
+
+	return WordList(words), nil
+}
+
+// Capitals returns a source drawing names from a list of world capitals.
+func Capitals() NameSource {
+	return WordList(capitals)
+}
+
+var capitals = []string{
+	"Abuja", "Accra", "Addis Ababa", "Algiers", "Amman", "Amsterdam", "Ankara",
+	"Athens", "Baghdad", "Bangkok", "Beijing", "Beirut", "Belgrade", "Berlin",
+	"Bern", "Bogotá", "Brasília", "Bratislava", "Brussels", "Bucharest",
+	"Budapest", "Buenos Aires", "Cairo", "Canberra", "Caracas", "Copenhagen",
+	"Dakar", "Damascus", "Dhaka", "Dublin", "Hanoi", "Havana", "Helsinki",
+	"Islamabad", "Jakarta", "Kabul", "Kampala", "Kathmandu", "Kyiv",
+	"Kinshasa", "Lima", "Lisbon", "Ljubljana", "London", "Luanda", "Madrid",
+	"Manila", "Mexico City", "Montevideo", "Moscow", "Nairobi", "New Delhi",
+	"Oslo", "Ottawa", "Paris", "Prague", "Quito", "Rabat", "Reykjavík", "Riga",
+	"Rome", "Santiago", "Seoul", "Sofia", "Stockholm", "Taipei", "Tallinn",
+	"Tehran", "Tokyo", "Tunis", "Vienna", "Vilnius", "Warsaw", "Wellington",
+	"Zagreb",
+}
+
+// Namer gives unique names to generated cities. The i-th city is named after
+// the i-th name drawn from its source, so names only depend on the seed of
+// the random generator. A name drawn again gets a numeric suffix, e.g.
+// `Paris_2`.
+type Namer struct {
+	source NameSource
+	r      *Rand
+	names  []string
+	used   map[string]bool
+	counts map[string]int
+}
+
+// NewNamer returns a namer drawing names from the source with the given
+// random generator.
+func NewNamer(source NameSource, r *Rand) *Namer {
+	return &Namer{
+		source: source,
+		r:      r,
+		used:   make(map[string]bool),
+		counts: make(map[string]int),
+	}
+}
+
+// Name returns the name of the i-th city, starting at 0.
+func (n *Namer) Name(i int) string {
+	for len(n.names) <= i {
+		base := n.source.Next(n.r)
+		name := base
+		for n.used[name] {
+			n.counts[base]++
+			name = base + "_" + strconv.Itoa(n.counts[base]+1)
+		}
+
+		n.used[name] = true
+		n.names = append(n.names, name)
+	}
+
+	return n.names[i]
+}
+
+// NamedGenerator names the cities built by a generator with a Namer instead
+// of `city_<n>`. It cannot be streamed: the Namer keeps every name drawn to
+// keep them unique, so it would need as much memory as the map.
+type NamedGenerator struct {
+	Generator
+	Namer *Namer
+}
+
+func (g NamedGenerator) Generate(cs Cities, r *Rand) error {
+	generated := NewCities()
+	if err := g.Generator.Generate(generated, r); err != nil {
+		return err
+	}
+
+	for _, city := range generated {
+		i, err := strconv.Atoi(strings.TrimPrefix(city.Name, "city_"))
+		if err != nil {
+			return fmt.Errorf("unexpected generated city name `%s`", city.Name)
+		}
+
+		city.Name = g.Namer.Name(i - 1)
+		cs[city.Name] = city
+	}
+
+	return nil
+}
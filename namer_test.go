@@ -0,0 +1,71 @@
+package invader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamer(t *testing.T) {
+	namer := NewNamer(WordList([]string{"Paris", "Lille"}), NewRand(1))
+
+	names := make(map[string]bool)
+	for i := 0; i < 6; i++ {
+		names[namer.Name(i)] = true
+	}
+
+	require.Equal(t, map[string]bool{
+		"Paris": true, "Paris_2": true, "Paris_3": true,
+		"Lille": true, "Lille_2": true, "Lille_3": true,
+	}, names)
+
+	// Names only depend on the index
+	again := NewNamer(WordList([]string{"Paris", "Lille"}), NewRand(1))
+	require.Equal(t, namer.Name(5), again.Name(5))
+	require.Equal(t, namer.Name(0), again.Name(0))
+}
+
+func TestSyllables(t *testing.T) {
+	namer := NewNamer(Syllables(), NewRand(1))
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		name := namer.Name(i)
+		require.False(t, seen[name], "duplicate name `%s`", name)
+		require.False(t, needsQuote(name))
+		seen[name] = true
+	}
+}
+
+func TestReadWordList(t *testing.T) {
+	source, err := ReadWordList(strings.NewReader("# cities\nParis\n\n  New York  \n"))
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"Paris", "New York"}, []string{source.Next(NewRand(1)), source.Next(NewRand(1))})
+
+	_, err = ReadWordList(strings.NewReader("# nothing\n"))
+	require.Error(t, err)
+}
+
+func TestNamedGenerator(t *testing.T) {
+	shape := GridShape{Width: 10, Height: 10}
+	g := NamedGenerator{
+		Generator: MazeGenerator{GridShape: shape, Algorithm: MazeEller},
+		Namer:     NewNamer(Capitals(), NewRand(2)),
+	}
+
+	cities := NewCities()
+	err := g.Generate(cities, NewRand(1))
+	require.NoError(t, err)
+	require.Len(t, cities, 100)
+	require.Contains(t, cities, "Paris")
+
+	// The same map as the unnamed generator
+	unnamed := NewCities()
+	err = g.Generator.Generate(unnamed, NewRand(1))
+	require.NoError(t, err)
+	require.Equal(t, unnamed.StructureFingerprint(), cities.StructureFingerprint())
+
+	// Names are kept in memory, so named maps cannot be streamed
+	_, ok := Generator(g).(StreamGenerator)
+	require.False(t, ok)
+}
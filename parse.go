@@ -0,0 +1,505 @@
+package invader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ParseErrorKind identifies the reason of a ParseError.
+type ParseErrorKind int
+
+const (
+	// KindMalformedLine means the line cannot be read, e.g. it is too long.
+	KindMalformedLine ParseErrorKind = iota
+	// KindTooManyBorders means the line declares more than four borders.
+	KindTooManyBorders
+	// KindReservedCharacter means a city name contains a reserved character.
+	KindReservedCharacter
+	// KindMalformedBorder means a border is not of the form `direction=city`.
+	KindMalformedBorder
+	// KindInvalidDirection means a border uses an unknown direction.
+	KindInvalidDirection
+	// KindSelfBorder means a city is bordered by itself.
+	KindSelfBorder
+	// KindInvalidHeader means a header line is malformed or uses an unknown key.
+	KindInvalidHeader
+	// KindMisplacedHeader means a header line appears after the first city.
+	KindMisplacedHeader
+	// KindUnsupportedVersion means the map declares a format version newer
+	// than FormatVersion.
+	KindUnsupportedVersion
+	// KindInvalidQuote means a quoted name is empty, unterminated or uses an
+	// unknown escape sequence.
+	KindInvalidQuote
+	// KindEmptyName means a city of a JSON map has no name.
+	KindEmptyName
+)
+
+func (k ParseErrorKind) String() string {
+	switch k {
+	case KindMalformedLine:
+		return "malformed line"
+	case KindTooManyBorders:
+		return "too many borders"
+	case KindReservedCharacter:
+		return "reserved character in city name"
+	case KindMalformedBorder:
+		return "malformed border"
+	case KindInvalidDirection:
+		return "invalid direction"
+	case KindSelfBorder:
+		return "city bordered by itself"
+	case KindInvalidHeader:
+		return "invalid header"
+	case KindMisplacedHeader:
+		return "header after the first city"
+	case KindUnsupportedVersion:
+		return "unsupported format version"
+	case KindInvalidQuote:
+		return "invalid quoted name"
+	case KindEmptyName:
+		return "empty city name"
+	}
+
+	return fmt.Sprintf("ParseErrorKind(%d)", int(k))
+}
+
+// ParseError describes a syntax error in a map, along with its position.
+// Errors of a JSON map are located by the position of the city in the
+// "cities" array instead of a line, and have no column.
+type ParseError struct {
+	File   string // name of the parsed file, if any
+	Line   int    // line number, starting at 1, 0 if unknown
+	Column int    // column in characters, starting at 1, 0 if unknown
+	Token  string // offending token
+	Kind   ParseErrorKind
+	Err    error // underlying error, if any
+}
+
+func (e *ParseError) Error() string {
+	var pos []string
+	if e.File != "" {
+		pos = append(pos, e.File)
+	}
+	if e.Line > 0 {
+		pos = append(pos, strconv.Itoa(e.Line))
+	}
+	if e.Column > 0 {
+		pos = append(pos, strconv.Itoa(e.Column))
+	}
+
+	msg := fmt.Sprintf("%s `%s`", e.Kind, e.Token)
+	if len(pos) > 0 {
+		msg = strings.Join(pos, ":") + ": " + msg
+	}
+
+	return msg
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// ParseErrors is returned by Parse when WithAllErrors is enabled and more than
+// one line is invalid.
+type ParseErrors []*ParseError
+
+func (errs ParseErrors) Error() string {
+	lines := make([]string, len(errs))
+	for i, err := range errs {
+		lines[i] = err.Error()
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// err returns nil if there is no error, the error itself if there is only one,
+// or all of them.
+func (errs ParseErrors) err() error {
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return errs
+	}
+}
+
+// Is reports whether one of the errors matches target, so errors.Is looks
+// into every ParseError.
+func (errs ParseErrors) Is(target error) bool {
+	for _, err := range errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// As finds the first error matching target, so errors.As gives access to
+// the first ParseError.
+func (errs ParseErrors) As(target interface{}) bool {
+	for _, err := range errs {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ParseOption configures the behaviour of Cities.Parse.
+type ParseOption func(cfg *parseConfig)
+
+type parseConfig struct {
+	strict    bool
+	allErrors bool
+	filename  string
+	header    *MapHeader
+
+	// versioned is set by scanMap if the map has a version header line, even
+	// an invalid one
+	versioned bool
+}
+
+// WithStrict enables the strict validation mode. Instead of silently
+// overwriting borders when lines disagree, every conflict is collected and
+// returned at once as a *ConflictError, and the Cities map is left untouched.
+func WithStrict() ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.strict = true
+	}
+}
+
+// WithAllErrors keeps parsing after an invalid line, so every syntax error is
+// returned at once as ParseErrors. Invalid lines are skipped.
+func WithAllErrors() ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.allErrors = true
+	}
+}
+
+// WithHeader stores the header declared by the map, if any, into h.
+func WithHeader(h *MapHeader) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.header = h
+	}
+}
+
+// WithFileName sets the file name reported by parse errors.
+func WithFileName(name string) ParseOption {
+	return func(cfg *parseConfig) {
+		cfg.filename = name
+	}
+}
+
+// Parse method reads from an io.Reader and populates the Cities map with City structs.
+// Each line from the reader should represent a city and its bordering cities.
+// The format of each line should be: "CityName Border1=CityName Border2=CityName ..."
+// For example: "Paris North=Lille South=Lyon East=Strasbourg West=Rouen"
+//
+// Names containing whitespaces or reserved characters are written between
+// double quotes, e.g. `"New York" south="Saint-Étienne=Nord"`, and every name
+// is normalized with NormalizeName.
+//
+// Blank lines are ignored and a `#` starts a comment running to the end of the
+// line. The map may begin with a header block of `#! key: value` lines, see
+// MapHeader.
+//
+// Syntax errors are reported as *ParseError, or as ParseErrors when
+// WithAllErrors is used.
+func (cs Cities) Parse(r io.Reader, opts ...ParseOption) error {
+	var cfg parseConfig
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	var validator *borderValidator
+	if cfg.strict {
+		validator = newBorderValidator()
+	}
+
+	err := scanMap(r, &cfg, func(lineno int, cityName string, borders []BorderDeclaration) {
+		// In strict mode, borders are only applied once the whole map has been validated
+		if validator != nil {
+			validator.add(lineno, cityName, borders)
+			return
+		}
+
+		city := cs.GetOrCreate(cityName)
+		for _, border := range borders {
+			city.SetDirection(border.Direction, cs.GetOrCreate(border.Neighbor))
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	if validator != nil {
+		return validator.apply(cs)
+	}
+
+	return nil
+}
+
+// scanMap reads a map line by line and calls fn for every city line, skipping
+// blank, comment and header lines. The header is stored according to the
+// configuration, and syntax errors are reported as described by Parse.
+func scanMap(r io.Reader, cfg *parseConfig, fn func(lineno int, cityName string, borders []BorderDeclaration)) error {
+	var header MapHeader
+	if cfg.header != nil {
+		header = *cfg.header
+	}
+
+	var errs ParseErrors
+	var seenCity bool
+	var lineno int
+	scanner := bufio.NewScanner(r)
+	for lineno = 1; scanner.Scan(); lineno++ {
+		var cityName string
+		var borders []BorderDeclaration
+		var err *ParseError
+
+		line := scanner.Text()
+		if isHeaderLine(line) {
+			if seenCity {
+				err = &ParseError{Line: lineno, Column: 1, Token: strings.TrimSpace(line), Kind: KindMisplacedHeader}
+			} else {
+				var key string
+				key, err = header.parseLine(line, lineno)
+				cfg.versioned = cfg.versioned || key == headerVersionKey
+			}
+		} else {
+			cityName, borders, err = parseLine(line, lineno)
+		}
+
+		if err != nil {
+			err.File = cfg.filename
+			if !cfg.allErrors {
+				return err
+			}
+
+			errs = append(errs, err)
+			continue
+		}
+
+		// Skip blank and comment lines
+		if cityName == "" {
+			continue
+		}
+
+		seenCity = true
+		fn(lineno, cityName, borders)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return &ParseError{File: cfg.filename, Line: lineno, Column: 1, Kind: KindMalformedLine, Err: err}
+	}
+
+	if cfg.header != nil {
+		*cfg.header = header
+	}
+
+	return errs.err()
+}
+
+// field is a whitespace separated token of a line, either a name or a
+// `key=value` pair, with its names unquoted.
+type field struct {
+	text   string // raw text of the field
+	column int
+
+	key      string
+	value    string
+	hasValue bool
+	// equalColumn and valueColumn locate the `=` and the value, if any.
+	equalColumn, valueColumn int
+	// junkColumn locates unexpected characters trailing the field, if any.
+	junkColumn int
+}
+
+// lineScanner reads a line rune by rune while keeping track of the column.
+type lineScanner struct {
+	line   string
+	pos    int // offset in bytes
+	column int // column of the rune at pos, starting at 1
+}
+
+func (s *lineScanner) eof() bool {
+	return s.pos >= len(s.line)
+}
+
+func (s *lineScanner) peek() rune {
+	r, _ := utf8.DecodeRuneInString(s.line[s.pos:])
+	return r
+}
+
+func (s *lineScanner) next() rune {
+	r, size := utf8.DecodeRuneInString(s.line[s.pos:])
+	s.pos += size
+	s.column++
+	return r
+}
+
+// atSeparator reports whether the scanner is at the end of a field.
+func (s *lineScanner) atSeparator() bool {
+	return s.eof() || unicode.IsSpace(s.peek())
+}
+
+// scanName reads a bare or a double quoted name. Bare names stop at the first
+// whitespace, `=` or `"`; quoted names may contain anything, with `\"`, `\\`
+// and `\n` as escape sequences.
+func (s *lineScanner) scanName(lineno int) (string, *ParseError) {
+	if s.eof() || s.peek() != '"' {
+		start := s.pos
+		for !s.atSeparator() && s.peek() != '=' && s.peek() != '"' {
+			s.next()
+		}
+
+		return s.line[start:s.pos], nil
+	}
+
+	start, column := s.pos, s.column
+	newError := func() *ParseError {
+		return &ParseError{Line: lineno, Column: column, Token: s.line[start:s.pos], Kind: KindInvalidQuote}
+	}
+
+	var name strings.Builder
+	s.next() // opening quote
+	for {
+		if s.eof() {
+			return "", newError()
+		}
+
+		switch r := s.next(); r {
+		case '"':
+			if name.Len() == 0 {
+				return "", newError()
+			}
+
+			return name.String(), nil
+		case '\\':
+			if s.eof() {
+				return "", newError()
+			}
+
+			switch e := s.next(); e {
+			case '"', '\\':
+				name.WriteRune(e)
+			case 'n':
+				name.WriteByte('\n')
+			default:
+				return "", newError()
+			}
+		default:
+			name.WriteRune(r)
+		}
+	}
+}
+
+// splitFields splits the line around whitespaces, like strings.Fields, but
+// understands quoted names and keeps track of the column of each field. A
+// field starting with `#` begins a comment, which is dropped along with the
+// rest of the line.
+func splitFields(line string, lineno int) (fields []field, perr *ParseError) {
+	s := &lineScanner{line: line, column: 1}
+	for {
+		for !s.eof() && unicode.IsSpace(s.peek()) {
+			s.next()
+		}
+
+		if s.eof() || s.peek() == '#' {
+			return fields, nil
+		}
+
+		start := s.pos
+		f := field{column: s.column}
+		if f.key, perr = s.scanName(lineno); perr != nil {
+			return nil, perr
+		}
+
+		if !s.eof() && s.peek() == '=' {
+			f.hasValue, f.equalColumn = true, s.column
+			s.next()
+
+			f.valueColumn = s.column
+			if f.value, perr = s.scanName(lineno); perr != nil {
+				return nil, perr
+			}
+		}
+
+		// Anything else until the next whitespace is unexpected
+		if !s.atSeparator() {
+			f.junkColumn = s.column
+			for !s.atSeparator() {
+				s.next()
+			}
+		}
+
+		f.text = line[start:s.pos]
+		fields = append(fields, f)
+	}
+}
+
+// parseLine parses a single line of the map format, returning the city name
+// and its declared borders. An empty city name is returned for blank and
+// comment lines. Names are returned in their normalized form.
+func parseLine(line string, lineno int) (cityName string, borders []BorderDeclaration, perr *ParseError) {
+	newError := func(kind ParseErrorKind, token string, column int, err error) *ParseError {
+		return &ParseError{Line: lineno, Column: column, Token: token, Kind: kind, Err: err}
+	}
+
+	fields, perr := splitFields(line, lineno)
+	switch {
+	case perr != nil:
+		return "", nil, perr
+	case len(fields) == 0:
+		return "", nil, nil
+	case len(fields) > 5: // a city and at most four borders
+		return "", nil, newError(KindTooManyBorders, fields[5].text, fields[5].column, nil)
+	}
+
+	city := fields[0]
+	switch {
+	case city.hasValue:
+		return "", nil, newError(KindReservedCharacter, city.text, city.equalColumn, nil)
+	case city.junkColumn > 0:
+		return "", nil, newError(KindReservedCharacter, city.text, city.junkColumn, nil)
+	}
+
+	cityName = NormalizeName(city.key)
+	borders = make([]BorderDeclaration, 0, len(fields)-1)
+	for _, border := range fields[1:] {
+		// If the border doesn't split into two non empty parts, then return an error
+		if !border.hasValue || border.value == "" || border.junkColumn > 0 {
+			return "", nil, newError(KindMalformedBorder, border.text, border.column, nil)
+		}
+
+		dir, err := ParseDirection(border.key)
+		if err != nil {
+			return "", nil, newError(KindInvalidDirection, border.key, border.column, err)
+		}
+
+		borderCityName := NormalizeName(border.value)
+		if borderCityName == cityName {
+			return "", nil, newError(KindSelfBorder, border.value, border.valueColumn, nil)
+		}
+
+		borders = append(borders, BorderDeclaration{
+			Line:      lineno,
+			City:      cityName,
+			Direction: dir,
+			Neighbor:  borderCityName,
+		})
+	}
+
+	return cityName, borders, nil
+}
@@ -0,0 +1,123 @@
+package invader
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseError(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		Input      string
+		WantKind   ParseErrorKind
+		WantLine   int
+		WantColumn int
+		WantToken  string
+	}{
+		{
+			Name:       "too many borders",
+			Input:      "a north=b south=c east=d west=e up=f",
+			WantKind:   KindTooManyBorders,
+			WantLine:   1,
+			WantColumn: 33,
+			WantToken:  "up=f",
+		},
+		{
+			Name:       "reserved character",
+			Input:      "a north=b\nbé=c north=d",
+			WantKind:   KindReservedCharacter,
+			WantLine:   2,
+			WantColumn: 3,
+			WantToken:  "bé=c",
+		},
+		{
+			Name:       "malformed border",
+			Input:      "a  north",
+			WantKind:   KindMalformedBorder,
+			WantLine:   1,
+			WantColumn: 4,
+			WantToken:  "north",
+		},
+		{
+			Name:       "empty neighbour",
+			Input:      "a north=",
+			WantKind:   KindMalformedBorder,
+			WantLine:   1,
+			WantColumn: 3,
+			WantToken:  "north=",
+		},
+		{
+			Name:       "invalid direction",
+			Input:      "a\tnorthwest=b",
+			WantKind:   KindInvalidDirection,
+			WantLine:   1,
+			WantColumn: 3,
+			WantToken:  "northwest",
+		},
+		{
+			Name:       "self border",
+			Input:      "a south=a",
+			WantKind:   KindSelfBorder,
+			WantLine:   1,
+			WantColumn: 9,
+			WantToken:  "a",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			cities := NewCities()
+			err := cities.Parse(strings.NewReader(tc.Input), WithFileName("test.map"))
+
+			var perr *ParseError
+			require.True(t, errors.As(err, &perr))
+			require.Equal(t, "test.map", perr.File)
+			require.Equal(t, tc.WantKind, perr.Kind)
+			require.Equal(t, tc.WantLine, perr.Line)
+			require.Equal(t, tc.WantColumn, perr.Column)
+			require.Equal(t, tc.WantToken, perr.Token)
+		})
+	}
+}
+
+func TestParseInvalidDirection(t *testing.T) {
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a up=b"))
+	require.ErrorIs(t, err, ErrInvalidDirection)
+	require.Contains(t, err.Error(), "`up`")
+}
+
+func TestParseAllErrors(t *testing.T) {
+	cities := NewCities()
+	input := "a north=b\nc nort=d\nb south=a\ne=f\n"
+	err := cities.Parse(strings.NewReader(input), WithAllErrors())
+
+	var errs ParseErrors
+	require.True(t, errors.As(err, &errs))
+	require.Len(t, errs, 2)
+	require.Equal(t, 2, errs[0].Line)
+	require.Equal(t, KindInvalidDirection, errs[0].Kind)
+	require.Equal(t, 4, errs[1].Line)
+	require.Equal(t, KindReservedCharacter, errs[1].Kind)
+
+	// Each error can also be reached individually
+	var perr *ParseError
+	require.True(t, errors.As(err, &perr))
+	require.Equal(t, 2, perr.Line)
+	require.True(t, errors.Is(err, errs[1]))
+
+	// Valid lines are still parsed
+	require.Len(t, cities, 2)
+}
+
+func TestParseComments(t *testing.T) {
+	input := "# comment line\n\n   \na north=b # trailing comment\n\t# indented comment\nb south=a\n"
+
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader(input))
+	require.NoError(t, err)
+	require.Len(t, cities, 2)
+}
@@ -0,0 +1,71 @@
+package invader
+
+import (
+	"hash/fnv"
+	"math/bits"
+)
+
+// Rand is the pseudo-random number generator used to generate maps and to run
+// simulations. It implements SplitMix64 along with fixed algorithms to draw
+// integers, floats and permutations, so a given seed always produces the same
+// sequence, whatever the Go version. Changing any of these algorithms changes
+// the maps and simulations produced from a seed and must be avoided.
+type Rand struct {
+	state uint64
+}
+
+// NewRand returns a generator initialized with the given seed.
+func NewRand(seed uint64) *Rand {
+	return &Rand{state: seed}
+}
+
+// SeedFromString returns the seed derived from a string, using the 64 bits
+// FNV-1a hash of the string.
+func SeedFromString(s string) uint64 {
+	h := fnv.New64a()
+	h.Write([]byte(s))
+	return h.Sum64()
+}
+
+// Uint64 returns a pseudo-random 64 bits value.
+func (r *Rand) Uint64() uint64 {
+	r.state += 0x9e3779b97f4a7c15
+	z := r.state
+	z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+	z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+	return z ^ (z >> 31)
+}
+
+// Intn returns a uniformly distributed value in [0, n), using Lemire's
+// multiply and shift method. It panics if n <= 0.
+func (r *Rand) Intn(n int) int {
+	if n <= 0 {
+		panic("invalid argument to Intn")
+	}
+
+	bound := uint64(n)
+	hi, lo := bits.Mul64(r.Uint64(), bound)
+	if lo < bound {
+		// Reject the values that would make the distribution biased
+		threshold := -bound % bound
+		for lo < threshold {
+			hi, lo = bits.Mul64(r.Uint64(), bound)
+		}
+	}
+
+	return int(hi)
+}
+
+// Float64 returns a uniformly distributed value in [0, 1), with 53 bits of
+// precision.
+func (r *Rand) Float64() float64 {
+	return float64(r.Uint64()>>11) / (1 << 53)
+}
+
+// Shuffle shuffles n elements using the Fisher-Yates algorithm, from the last
+// element to the first one.
+func (r *Rand) Shuffle(n int, swap func(i, j int)) {
+	for i := n - 1; i > 0; i-- {
+		swap(i, r.Intn(i+1))
+	}
+}
@@ -0,0 +1,61 @@
+package invader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRandUint64(t *testing.T) {
+	// Reference values of SplitMix64
+	r := NewRand(1234567)
+	want := []uint64{
+		6457827717110365317,
+		3203168211198807973,
+		9817491932198370423,
+		4593380528125082431,
+		16408922859458223821,
+	}
+
+	for _, w := range want {
+		require.Equal(t, w, r.Uint64())
+	}
+}
+
+func TestRandIntn(t *testing.T) {
+	r := NewRand(42)
+	counts := make([]int, 6)
+	for i := 0; i < 6000; i++ {
+		n := r.Intn(6)
+		require.True(t, n >= 0 && n < 6)
+		counts[n]++
+	}
+
+	for _, count := range counts {
+		require.InDelta(t, 1000, count, 150)
+	}
+
+	require.Panics(t, func() { r.Intn(0) })
+}
+
+func TestRandFloat64(t *testing.T) {
+	r := NewRand(42)
+	for i := 0; i < 1000; i++ {
+		f := r.Float64()
+		require.True(t, f >= 0 && f < 1)
+	}
+}
+
+func TestRandShuffle(t *testing.T) {
+	values := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+	NewRand(42).Shuffle(len(values), func(i, j int) { values[i], values[j] = values[j], values[i] })
+	require.ElementsMatch(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, values)
+	require.NotEqual(t, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}, values)
+}
+
+func TestSeedFromString(t *testing.T) {
+	// FNV-1a offset basis
+	require.Equal(t, uint64(14695981039346656037), SeedFromString(""))
+	require.Equal(t, SeedFromString("foo"), SeedFromString("foo"))
+	require.NotEqual(t, SeedFromString("foo"), SeedFromString("bar"))
+}
@@ -0,0 +1,297 @@
+package invader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+)
+
+// RecordingVersion is the version of the header of the recordings written by
+// AlienInvaders.Record.
+const RecordingVersion = 1
+
+var (
+	// ErrReplayDiverged is returned when a replay does not produce the events
+	// of its recording.
+	ErrReplayDiverged = errors.New("replay diverged from the recording")
+	// ErrReplayEnded is returned when stepping a replay past the end of its
+	// recording.
+	ErrReplayEnded = errors.New("end of the recording")
+)
+
+// jsonRecording is the first line of a recording, followed by the events of
+// the simulation as written by JSONObserver:
+//
+//	{"version":1,"type":"recording","seed":"1234","fingerprint":"5140ca57...","map":"a north=b\nb south=a\n"}
+//
+// The seed is the state of the random source when the recording started, as
+// a string since it may not fit in a double.
+type jsonRecording struct {
+	Version     int    `json:"version"`
+	Type        string `json:"type"`
+	Seed        uint64 `json:"seed,string"`
+	Fingerprint string `json:"fingerprint"`
+	Map         string `json:"map"`
+}
+
+const recordingType = "recording"
+
+// Record writes the map and the state of the random source to w, followed by
+// every event of the simulation, so the simulation can be replayed by
+// NewReplay. It must be called before GenerateAliens, and the returned
+// observer must be flushed once the simulation has ended.
+func (ai *AlienInvaders) Record(w io.Writer) (*JSONObserver, error) {
+	if ai.nextID > 0 {
+		return nil, fmt.Errorf("cannot record a simulation once its aliens have been placed")
+	}
+
+	var m strings.Builder
+	ai.cities.Print(&m, PrintHeader(ai.header))
+
+	o := NewJSONObserver(w)
+	err := o.enc.Encode(jsonRecording{
+		Version:     RecordingVersion,
+		Type:        recordingType,
+		Seed:        ai.rand.state,
+		Fingerprint: ai.Fingerprint(),
+		Map:         m.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ai.observers = append(ai.observers, o)
+	return o, nil
+}
+
+// Replay replays a recorded simulation, checking that it produces the same
+// events as the recording. Replays are driven by the seed of the recording,
+// the events being read on the fly so long recordings are never held in
+// memory.
+//
+// A recording of an interrupted simulation is replayed up to the iteration it
+// was interrupted in.
+type Replay struct {
+	ai  *AlienInvaders
+	dec *json.Decoder
+
+	pending     []Event // events read ahead of the simulation
+	eof         bool
+	interrupted bool
+	err         error // first read error or divergence
+
+	current []Event // events of the iteration being replayed
+}
+
+// NewReplay reads the header of the recording from r, and places the aliens
+// on the recorded map. The simulation writes its progress to w, like the one
+// returned by NewAlienInvaders with the given options.
+func NewReplay(logger *log.Logger, w io.Writer, r io.Reader, opts ...InvaderOption) (*Replay, error) {
+	rp := &Replay{dec: json.NewDecoder(r)}
+
+	var header jsonRecording
+	if err := rp.dec.Decode(&header); err != nil {
+		return nil, fmt.Errorf("unable to read the recording header: %w", err)
+	}
+
+	switch {
+	case header.Type != recordingType:
+		return nil, fmt.Errorf("not a recording, got `%s` instead", header.Type)
+	case header.Version != RecordingVersion:
+		return nil, fmt.Errorf("unsupported recording version %d, expected %d", header.Version, RecordingVersion)
+	}
+
+	opts = append([]InvaderOption{WithSeed(header.Seed), WithObserver(rp)}, opts...)
+	rp.ai = NewAlienInvaders(logger, w, opts...)
+	if err := rp.ai.ParseMap(strings.NewReader(header.Map)); err != nil {
+		return nil, fmt.Errorf("unable to parse the recorded map: %w", err)
+	}
+
+	if fingerprint := rp.ai.Fingerprint(); fingerprint != header.Fingerprint {
+		return nil, fmt.Errorf("%w: map fingerprint %s, expected %s", ErrReplayDiverged, fingerprint, header.Fingerprint)
+	}
+
+	// Read the placement ahead to know how many aliens were placed
+	for rp.err == nil && !rp.eof {
+		if e, ok := rp.readAhead().(AlienPlaced); !ok || e.Iteration != 0 {
+			break
+		}
+	}
+
+	aliens := 0
+	for _, e := range rp.pending {
+		if _, ok := e.(AlienPlaced); ok {
+			aliens++
+		}
+	}
+
+	if aliens > 0 {
+		if err := rp.ai.GenerateAliens(aliens); err != nil {
+			return nil, fmt.Errorf("unable to place the recorded aliens: %w", err)
+		}
+	}
+
+	if rp.err != nil {
+		return nil, rp.err
+	}
+
+	return rp, nil
+}
+
+// readAhead reads the next event of the recording into the pending events,
+// and returns it. It returns nil at the end of the recording or on error.
+func (rp *Replay) readAhead() Event {
+	var je jsonEvent
+	if err := rp.dec.Decode(&je); err != nil {
+		if err == io.EOF {
+			rp.eof = true
+		} else {
+			rp.err = fmt.Errorf("unable to read the recording: %w", err)
+		}
+		return nil
+	}
+
+	e, err := je.event()
+	if err != nil {
+		rp.err = fmt.Errorf("unable to read the recording: %w", err)
+		return nil
+	}
+
+	rp.pending = append(rp.pending, e)
+	return e
+}
+
+// peek returns the next event of the recording without consuming it, or nil
+// at the end of the recording.
+func (rp *Replay) peek() Event {
+	if len(rp.pending) > 0 {
+		return rp.pending[0]
+	}
+
+	if rp.err != nil || rp.eof {
+		return nil
+	}
+
+	return rp.readAhead()
+}
+
+// Observe implements Observer, checking every event of the replay against
+// the recording.
+func (rp *Replay) Observe(e Event) {
+	if rp.err != nil || rp.interrupted {
+		return
+	}
+
+	rp.current = append(rp.current, e)
+
+	expected := rp.peek()
+	if rp.err != nil {
+		return
+	}
+
+	if ended, ok := expected.(SimulationEnded); ok && ended.Reason == EndCancelled {
+		rp.interrupted = true
+		return
+	}
+
+	got, _ := json.Marshal(newJSONEvent(e))
+	if expected == nil {
+		rp.err = fmt.Errorf("%w: got %s after the end of the recording", ErrReplayDiverged, got)
+		return
+	}
+
+	rp.pending = rp.pending[1:]
+	if want, _ := json.Marshal(newJSONEvent(expected)); !bytes.Equal(got, want) {
+		rp.err = fmt.Errorf("%w at iteration %d: expected %s, got %s", ErrReplayDiverged, rp.ai.iteration, want, got)
+	}
+}
+
+// Simulation returns the replayed simulation, in the state of the last
+// replayed iteration.
+func (rp *Replay) Simulation() *AlienInvaders {
+	return rp.ai
+}
+
+// Iteration returns the number of replayed iterations.
+func (rp *Replay) Iteration() int {
+	return rp.ai.iteration
+}
+
+// Done reports whether every iteration of the recording has been replayed,
+// or the replay has failed.
+func (rp *Replay) Done() bool {
+	if rp.err != nil || rp.interrupted {
+		return true
+	}
+
+	_, ended := rp.peek().(SimulationEnded)
+	return ended || rp.peek() == nil
+}
+
+// Step replays the next iteration and returns its events. It returns
+// ErrReplayEnded once every iteration has been replayed, or an error wrapping
+// ErrReplayDiverged at the first event differing from the recording.
+func (rp *Replay) Step(ctx context.Context) ([]Event, error) {
+	if rp.err != nil {
+		return nil, rp.err
+	}
+
+	if rp.Done() {
+		return nil, ErrReplayEnded
+	}
+
+	rp.current = nil
+	if err := rp.ai.step(ctx); err != nil && err != ErrAllAliensAreKO {
+		return nil, err
+	}
+
+	return rp.current, rp.err
+}
+
+// Seek replays the iterations up to the given one, or up to the end of the
+// recording.
+func (rp *Replay) Seek(ctx context.Context, iteration int) error {
+	for rp.ai.iteration < iteration && !rp.Done() {
+		if _, err := rp.Step(ctx); err != nil {
+			return err
+		}
+	}
+
+	return rp.err
+}
+
+// Finish replays the remaining iterations and checks that the simulation ends
+// like the recorded one, which is returned.
+func (rp *Replay) Finish(ctx context.Context) (SimulationEnded, error) {
+	for !rp.Done() {
+		if _, err := rp.Step(ctx); err != nil {
+			return SimulationEnded{}, err
+		}
+	}
+
+	if rp.err != nil {
+		return SimulationEnded{}, rp.err
+	}
+
+	ended, ok := rp.peek().(SimulationEnded)
+	if rp.interrupted || !ok {
+		return SimulationEnded{}, fmt.Errorf("the recording has been interrupted at iteration %d", rp.ai.iteration)
+	}
+
+	// Emit the end of the simulation, without running any more iteration
+	rp.current = nil
+	if err := rp.ai.Run(ctx, rp.ai.iteration); err != nil && err != ErrAllAliensAreKO {
+		return SimulationEnded{}, err
+	}
+
+	if rp.err == nil && rp.peek() != nil {
+		rp.err = fmt.Errorf("%w: unexpected events after the end of the recording", ErrReplayDiverged)
+	}
+
+	return ended, rp.err
+}
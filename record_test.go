@@ -0,0 +1,150 @@
+package invader
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// recordSimulation runs a simulation of the given number of aliens on a grid
+// and returns its recording along with its text output.
+func recordSimulation(t *testing.T, seed uint64, aliens, limit int) (string, string) {
+	t.Helper()
+
+	cities := NewCities()
+	err := GridGenerator{GridShape: GridShape{Width: 6, Height: 6}}.Generate(cities, NewRand(1))
+	require.NoError(t, err)
+
+	var input bytes.Buffer
+	cities.Print(&input)
+
+	var out, rec bytes.Buffer
+	ai := NewAlienInvaders(testInvaderLogger, &out, WithSeed(seed))
+	err = ai.ParseMap(&input)
+	require.NoError(t, err)
+
+	recorder, err := ai.Record(&rec)
+	require.NoError(t, err)
+
+	err = ai.GenerateAliens(aliens)
+	require.NoError(t, err)
+
+	err = ai.Run(context.Background(), limit)
+	if err != nil {
+		require.Equal(t, ErrAllAliensAreKO, err)
+	}
+
+	err = recorder.Flush()
+	require.NoError(t, err)
+
+	ai.PrintMap()
+	return rec.String(), out.String()
+}
+
+func TestReplay(t *testing.T) {
+	for _, limit := range []int{0, 3, 1000} {
+		recording, output := recordSimulation(t, 42, 10, limit)
+
+		var out bytes.Buffer
+		replay, err := NewReplay(testInvaderLogger, &out, strings.NewReader(recording))
+		require.NoError(t, err)
+
+		ended, err := replay.Finish(context.Background())
+		require.NoError(t, err)
+		require.LessOrEqual(t, ended.Iteration, limit)
+		require.Equal(t, ended.Iteration, replay.Iteration())
+
+		replay.Simulation().PrintMap()
+		require.Equal(t, output, out.String())
+	}
+}
+
+func TestReplayStep(t *testing.T) {
+	recording, _ := recordSimulation(t, 42, 10, 50)
+
+	replay, err := NewReplay(testInvaderLogger, testInvaderDefaultWriter, strings.NewReader(recording))
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	for i := 1; !replay.Done(); i++ {
+		events, err := replay.Step(ctx)
+		require.NoError(t, err)
+		require.Equal(t, i, replay.Iteration())
+		require.NotEmpty(t, events)
+		require.IsType(t, IterationCompleted{}, events[len(events)-1])
+	}
+
+	_, err = replay.Step(ctx)
+	require.ErrorIs(t, err, ErrReplayEnded)
+
+	_, err = replay.Finish(ctx)
+	require.NoError(t, err)
+}
+
+func TestReplaySeek(t *testing.T) {
+	recording, _ := recordSimulation(t, 7, 6, 1000)
+
+	replay, err := NewReplay(testInvaderLogger, testInvaderDefaultWriter, strings.NewReader(recording))
+	require.NoError(t, err)
+
+	err = replay.Seek(context.Background(), 5)
+	require.NoError(t, err)
+	require.Equal(t, 5, replay.Iteration())
+
+	// The replay is in the state of a simulation stopped at the same iteration
+	cities := NewCities()
+	err = GridGenerator{GridShape: GridShape{Width: 6, Height: 6}}.Generate(cities, NewRand(1))
+	require.NoError(t, err)
+
+	ai := NewAlienInvaders(testInvaderLogger, testInvaderDefaultWriter, WithSeed(7))
+	ai.cities = cities
+	err = ai.GenerateAliens(6)
+	require.NoError(t, err)
+
+	err = ai.Run(context.Background(), 5)
+	require.NoError(t, err)
+
+	require.Equal(t, ai.Fingerprint(), replay.Simulation().Fingerprint())
+	require.Equal(t, len(ai.Outcome().Alive), len(replay.Simulation().Outcome().Alive))
+}
+
+func TestReplayDiverged(t *testing.T) {
+	recording, _ := recordSimulation(t, 42, 10, 1000)
+
+	// Change the destination of the first move
+	lines := strings.Split(recording, "\n")
+	for i, line := range lines {
+		if strings.Contains(line, `"alien_moved"`) {
+			lines[i] = strings.Replace(line, `"to":"city_`, `"to":"elsewhere_`, 1)
+			break
+		}
+	}
+
+	replay, err := NewReplay(testInvaderLogger, testInvaderDefaultWriter, strings.NewReader(strings.Join(lines, "\n")))
+	require.NoError(t, err)
+
+	_, err = replay.Finish(context.Background())
+	require.ErrorIs(t, err, ErrReplayDiverged)
+	require.Contains(t, err.Error(), "at iteration 1: expected")
+}
+
+func TestReplayInvalid(t *testing.T) {
+	_, err := NewReplay(testInvaderLogger, testInvaderDefaultWriter, strings.NewReader(`{"version":1,"type":"alien_moved"}`))
+	require.Error(t, err)
+
+	// The map doesn't match its fingerprint
+	_, err = NewReplay(testInvaderLogger, testInvaderDefaultWriter, strings.NewReader(`{"version":1,"type":"recording","seed":"1","fingerprint":"00","map":"a north=b\n"}`))
+	require.ErrorIs(t, err, ErrReplayDiverged)
+
+	// Aliens cannot be recorded once placed
+	ai := NewAlienInvaders(testInvaderLogger, testInvaderDefaultWriter)
+	err = ai.ParseMap(strings.NewReader("a north=b"))
+	require.NoError(t, err)
+	err = ai.GenerateAliens(1)
+	require.NoError(t, err)
+	_, err = ai.Record(&bytes.Buffer{})
+	require.Error(t, err)
+}
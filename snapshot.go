@@ -0,0 +1,155 @@
+package invader
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// SnapshotVersion is the version of the snapshots written by
+// AlienInvaders.SaveSnapshot.
+const SnapshotVersion = 1
+
+// jsonSnapshot is the JSON representation of the state of a simulation:
+//
+//	{
+//	  "version": 1,
+//	  "iteration": 42,
+//	  "rand": "11400714819323198485",
+//	  "next_id": 3,
+//	  "map": {"version": 1, "cities": [{"name": "a", "borders": {"north": "b"}}, {"name": "b"}]},
+//	  "map_version": 1,
+//	  "aliens": [{"id": 2, "city": "a", "state": "alive"}],
+//	  "destroyed": [{"name": "c", "borders": {"east": "a"}}]
+//	}
+//
+// The map holds the remaining cities in the format of Cities.EncodeJSON, and
+// "map_version" the format version declared by the original map, if any. The
+// aliens are the alive and trapped ones, in ID order. The state of the random
+// source is written as a string since it may not fit in a double.
+type jsonSnapshot struct {
+	Version    int                 `json:"version"`
+	Iteration  int                 `json:"iteration"`
+	Rand       uint64              `json:"rand,string"`
+	NextID     uint                `json:"next_id"`
+	Map        jsonMap             `json:"map"`
+	MapVersion int                 `json:"map_version,omitempty"`
+	Aliens     []jsonAlien         `json:"aliens"`
+	Destroyed  []jsonDestroyedCity `json:"destroyed,omitempty"`
+}
+
+type jsonAlien struct {
+	ID    uint   `json:"id"`
+	City  string `json:"city"`
+	State string `json:"state"`
+}
+
+type jsonDestroyedCity struct {
+	Name    string               `json:"name"`
+	Borders map[Direction]string `json:"borders,omitempty"`
+}
+
+// SaveSnapshot writes the full state of the simulation to w: the remaining
+// cities and their borders, the aliens, the destroyed cities, the iteration
+// and the state of the random source. A simulation restored by LoadSnapshot
+// continues exactly like the saved one would have.
+func (ai *AlienInvaders) SaveSnapshot(w io.Writer) error {
+	s := jsonSnapshot{
+		Version:    SnapshotVersion,
+		Iteration:  ai.iteration,
+		Rand:       ai.rand.state,
+		NextID:     ai.nextID,
+		Map:        ai.cities.jsonMap(ai.header),
+		MapVersion: ai.header.Version,
+		Aliens:     make([]jsonAlien, 0, len(ai.aliens)+len(ai.trapped)),
+	}
+
+	for _, aliens := range [][]*Alien{ai.aliens, ai.trapped} {
+		for _, alien := range aliens {
+			s.Aliens = append(s.Aliens, jsonAlien{ID: alien.ID, City: alien.CurrentCity.Name, State: alien.State.String()})
+		}
+	}
+
+	for _, destroyed := range ai.destroyed {
+		s.Destroyed = append(s.Destroyed, jsonDestroyedCity{Name: destroyed.Name, Borders: destroyed.Borders})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(&s)
+}
+
+// LoadSnapshot restores the state of a simulation written by SaveSnapshot,
+// replacing the map and the aliens of the simulation. Observers are kept and
+// receive the events of the following iterations.
+func (ai *AlienInvaders) LoadSnapshot(r io.Reader) error {
+	var s jsonSnapshot
+	if err := json.NewDecoder(r).Decode(&s); err != nil {
+		return fmt.Errorf("unable to decode snapshot: %w", err)
+	}
+
+	if s.Version != SnapshotVersion {
+		return fmt.Errorf("unsupported snapshot version %d, expected %d", s.Version, SnapshotVersion)
+	}
+
+	var header MapHeader
+	cities := NewCities()
+	if err := cities.decodeJSONMap(s.Map, parseConfig{header: &header}); err != nil {
+		return fmt.Errorf("invalid snapshot map: %w", err)
+	}
+
+	var aliens, trapped []*Alien
+	ids := make(map[uint]struct{}, len(s.Aliens))
+	for _, ja := range s.Aliens {
+		if _, ok := ids[ja.ID]; ok {
+			return fmt.Errorf("alien %s: duplicate ID", alienName(ja.ID))
+		}
+		ids[ja.ID] = struct{}{}
+
+		city, ok := cities.Get(ja.City)
+		switch {
+		case !ok:
+			return fmt.Errorf("alien %s: %w: `%s`", alienName(ja.ID), ErrCityNotFound, ja.City)
+		case city.Alien != nil:
+			return fmt.Errorf("alien %s: city `%s` already occupied by alien %s", alienName(ja.ID), ja.City, city.Alien.Name())
+		case ja.ID == 0 || ja.ID > s.NextID:
+			return fmt.Errorf("alien %s: invalid ID, the next ID is %d", alienName(ja.ID), s.NextID)
+		}
+
+		alien := NewAlien(ja.ID, city)
+		switch ja.State {
+		case Alive.String():
+			aliens = append(aliens, alien)
+		case Trapped.String():
+			alien.State = Trapped
+			trapped = append(trapped, alien)
+		default:
+			return fmt.Errorf("alien %s: invalid state `%s`", alien.Name(), ja.State)
+		}
+	}
+
+	// Aliens move in ID order
+	sort.Slice(aliens, func(i, j int) bool {
+		return aliens[i].ID < aliens[j].ID
+	})
+
+	destroyed := make([]DestroyedCity, 0, len(s.Destroyed))
+	for _, jd := range s.Destroyed {
+		borders := jd.Borders
+		if borders == nil {
+			borders = make(map[Direction]string)
+		}
+
+		destroyed = append(destroyed, DestroyedCity{Name: jd.Name, Borders: borders})
+	}
+
+	header.Version = s.MapVersion
+	ai.cities, ai.header = cities, header
+	ai.aliens, ai.trapped, ai.destroyed = aliens, trapped, destroyed
+	ai.iteration, ai.nextID = s.Iteration, s.NextID
+	ai.rand = NewRand(s.Rand)
+
+	ai.logger.Printf("restored %d cities and %d aliens at iteration %d", len(cities), len(aliens)+len(trapped), s.Iteration)
+	return nil
+}
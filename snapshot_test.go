@@ -0,0 +1,140 @@
+package invader
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// newGridSimulation returns a simulation of the given number of aliens on a
+// grid, recording its events.
+func newGridSimulation(t *testing.T, seed uint64, aliens int) (*AlienInvaders, *[]Event) {
+	t.Helper()
+
+	events := []Event{}
+	ai := NewAlienInvaders(testInvaderLogger, testInvaderDefaultWriter, WithSeed(seed), WithObserver(ObserverFunc(func(e Event) {
+		events = append(events, e)
+	})))
+
+	err := GridGenerator{GridShape: GridShape{Width: 8, Height: 8}}.Generate(ai.cities, NewRand(1))
+	require.NoError(t, err)
+
+	err = ai.GenerateAliens(aliens)
+	require.NoError(t, err)
+
+	return ai, &events
+}
+
+func TestSnapshotResume(t *testing.T) {
+	ctx := context.Background()
+
+	// Uninterrupted simulation
+	full, fullEvents := newGridSimulation(t, 3, 20)
+	fullErr := full.Run(ctx, 1000)
+
+	// Simulation interrupted at iteration 5, then resumed
+	interrupted, _ := newGridSimulation(t, 3, 20)
+	err := interrupted.Run(ctx, 5)
+	require.NoError(t, err)
+
+	var snapshot bytes.Buffer
+	err = interrupted.SaveSnapshot(&snapshot)
+	require.NoError(t, err)
+
+	resumed, resumedEvents := newGridSimulation(t, 99, 1)
+	*resumedEvents = nil
+	err = resumed.LoadSnapshot(&snapshot)
+	require.NoError(t, err)
+	require.Equal(t, 5, resumed.Iteration())
+
+	resumedErr := resumed.Run(ctx, 1000)
+	require.Equal(t, fullErr, resumedErr)
+
+	// The resumed simulation goes on exactly like the uninterrupted one
+	var after []Event
+	for _, e := range *fullEvents {
+		if e, ok := e.(AlienPlaced); ok && e.Iteration == 0 {
+			continue
+		}
+
+		after = append(after, e)
+	}
+
+	skip := 0
+	for skip < len(after) {
+		if e, ok := after[skip].(IterationCompleted); ok && e.Iteration == 5 {
+			break
+		}
+		skip++
+	}
+
+	require.Equal(t, after[skip+1:], *resumedEvents)
+	require.Equal(t, full.Fingerprint(), resumed.Fingerprint())
+	require.Equal(t, full.Outcome().Destroyed, resumed.Outcome().Destroyed)
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	ai, _ := newGridSimulation(t, 8, 30)
+	ai.header = MapHeader{Name: "grid", Aliens: 30}
+	err := ai.Run(context.Background(), 20)
+	if err != nil {
+		require.Equal(t, ErrAllAliensAreKO, err)
+	}
+
+	var saved bytes.Buffer
+	err = ai.SaveSnapshot(&saved)
+	require.NoError(t, err)
+
+	loaded := NewAlienInvaders(testInvaderLogger, testInvaderDefaultWriter)
+	err = loaded.LoadSnapshot(bytes.NewReader(saved.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, ai.header.Name, loaded.Header().Name)
+
+	var again bytes.Buffer
+	err = loaded.SaveSnapshot(&again)
+	require.NoError(t, err)
+	require.Equal(t, saved.String(), again.String())
+}
+
+func TestLoadSnapshotError(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Snapshot string
+	}{
+		{
+			Name:     "unsupported version",
+			Snapshot: `{"version": 99}`,
+		},
+		{
+			Name:     "unknown city",
+			Snapshot: `{"version": 1, "next_id": 1, "map": {"cities": [{"name": "a"}]}, "aliens": [{"id": 1, "city": "b", "state": "alive"}]}`,
+		},
+		{
+			Name:     "duplicate alien",
+			Snapshot: `{"version": 1, "next_id": 2, "map": {"cities": [{"name": "a"}, {"name": "b"}]}, "aliens": [{"id": 1, "city": "a", "state": "alive"}, {"id": 1, "city": "b", "state": "alive"}]}`,
+		},
+		{
+			Name:     "occupied city",
+			Snapshot: `{"version": 1, "next_id": 2, "map": {"cities": [{"name": "a"}]}, "aliens": [{"id": 1, "city": "a", "state": "alive"}, {"id": 2, "city": "a", "state": "alive"}]}`,
+		},
+		{
+			Name:     "invalid state",
+			Snapshot: `{"version": 1, "next_id": 1, "map": {"cities": [{"name": "a"}]}, "aliens": [{"id": 1, "city": "a", "state": "killed"}]}`,
+		},
+		{
+			Name:     "invalid id",
+			Snapshot: `{"version": 1, "next_id": 1, "map": {"cities": [{"name": "a"}]}, "aliens": [{"id": 2, "city": "a", "state": "alive"}]}`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			ai := NewAlienInvaders(testInvaderLogger, testInvaderDefaultWriter)
+			err := ai.LoadSnapshot(strings.NewReader(tc.Snapshot))
+			require.Error(t, err)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package invader
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MapStats summarizes the structure of a map.
+type MapStats struct {
+	Cities  int `json:"cities"`
+	Borders int `json:"borders"`
+	// Degrees maps a number of borders to the number of cities having it.
+	Degrees map[int]int `json:"degrees"`
+	// DeadEnds is the number of cities with a single border.
+	DeadEnds int `json:"dead_ends"`
+	// Components holds the size of every connected component, biggest first.
+	Components []int `json:"components"`
+	// Diameter is the longest shortest path between two connected cities.
+	Diameter int `json:"diameter"`
+	// AveragePathLength is the average length of the shortest paths between
+	// every pair of connected cities.
+	AveragePathLength float64 `json:"average_path_length"`
+	// Fingerprint identifies the map, see Cities.Fingerprint.
+	Fingerprint string `json:"fingerprint"`
+	// StructureFingerprint identifies the map regardless of the city names,
+	// see Cities.StructureFingerprint.
+	StructureFingerprint string `json:"structure_fingerprint"`
+}
+
+// Stats computes the statistics of the map. Path related statistics walk the
+// map from every city, so they take O(cities²) time spread over the available
+// CPUs.
+func (cs Cities) Stats() MapStats {
+	stats := MapStats{
+		Cities:     len(cs),
+		Degrees:    make(map[int]int),
+		Components: []int{},
+	}
+
+	cs.IterateBorders(func(*City, Direction, *City) {
+		stats.Borders++
+	})
+
+	for _, city := range cs {
+		degree := len(city.GetAvailableDirections())
+		stats.Degrees[degree]++
+		if degree == 1 {
+			stats.DeadEnds++
+		}
+	}
+
+	for _, component := range cs.Components() {
+		stats.Components = append(stats.Components, len(component))
+	}
+
+	stats.Fingerprint = cs.Fingerprint()
+	stats.StructureFingerprint = cs.StructureFingerprint()
+
+	g := cs.graph()
+	paths := g.shortestPaths()
+	stats.Diameter = paths.longest
+	if paths.pairs > 0 {
+		stats.AveragePathLength = float64(paths.total) / float64(paths.pairs)
+	}
+
+	return stats
+}
+
+// pathsSummary summarizes the shortest paths between every pair of connected
+// cities.
+type pathsSummary struct {
+	pairs   int // number of ordered pairs of connected cities
+	total   int // sum of the shortest path lengths
+	longest int
+}
+
+// shortestPaths walks the graph from every city, spreading the work over the
+// available CPUs.
+func (g *graph) shortestPaths() (summary pathsSummary) {
+	workers := runtime.GOMAXPROCS(0)
+	summaries := make([]pathsSummary, workers)
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+
+			s := &summaries[w]
+			dist := g.newDistances()
+			queue := make([]int, 0, len(g.cities))
+			for from := w; from < len(g.cities); from += workers {
+				queue = g.bfs(from, dist, queue)
+				for _, to := range queue[1:] {
+					s.total += dist[to]
+				}
+
+				s.pairs += len(queue) - 1
+				if last := dist[queue[len(queue)-1]]; last > s.longest {
+					s.longest = last
+				}
+
+				g.reset(dist, queue)
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	for _, s := range summaries {
+		summary.pairs += s.pairs
+		summary.total += s.total
+		if s.longest > summary.longest {
+			summary.longest = s.longest
+		}
+	}
+
+	return
+}
+
+// Print writes the statistics in a human-readable form.
+func (s MapStats) Print(w io.Writer) {
+	degrees := make([]int, 0, len(s.Degrees))
+	for degree := range s.Degrees {
+		degrees = append(degrees, degree)
+	}
+	sort.Ints(degrees)
+
+	distribution := make([]string, len(degrees))
+	for i, degree := range degrees {
+		distribution[i] = fmt.Sprintf("%d: %d", degree, s.Degrees[degree])
+	}
+
+	sizes := make([]string, len(s.Components))
+	for i, size := range s.Components {
+		sizes[i] = fmt.Sprint(size)
+	}
+
+	fmt.Fprintf(w, "cities:               %d\n", s.Cities)
+	fmt.Fprintf(w, "borders:              %d\n", s.Borders)
+	fmt.Fprintf(w, "degrees:              %s\n", strings.Join(distribution, ", "))
+	fmt.Fprintf(w, "dead ends:            %d\n", s.DeadEnds)
+	fmt.Fprintf(w, "components:           %d (%s)\n", len(s.Components), strings.Join(sizes, ", "))
+	fmt.Fprintf(w, "diameter:             %d\n", s.Diameter)
+	fmt.Fprintf(w, "average path length:  %.2f\n", s.AveragePathLength)
+	fmt.Fprintf(w, "fingerprint:          %s\n", s.Fingerprint)
+	fmt.Fprintf(w, "structure:            %s\n", s.StructureFingerprint)
+}
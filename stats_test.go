@@ -0,0 +1,60 @@
+package invader
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStats(t *testing.T) {
+	// a - b - c   d - e   f
+	//     |
+	//     g
+	cities := NewCities()
+	err := cities.Parse(strings.NewReader("a east=b\nb east=c south=g\nd east=e\nf\n"))
+	require.NoError(t, err)
+
+	stats := cities.Stats()
+	require.Equal(t, 7, stats.Cities)
+	require.Equal(t, 4, stats.Borders)
+	require.Equal(t, map[int]int{0: 1, 1: 5, 3: 1}, stats.Degrees)
+	require.Equal(t, 5, stats.DeadEnds)
+	require.Equal(t, []int{4, 2, 1}, stats.Components)
+	require.Equal(t, 2, stats.Diameter)
+
+	// 4 cities component: 3 paths of 1 and 3 paths of 2, both ways; 2 cities
+	// component: 1 path of 1, both ways
+	require.InDelta(t, float64(2*(3+6)+2)/float64(2*6+2), stats.AveragePathLength, 1e-9)
+	require.Equal(t, cities.Fingerprint(), stats.Fingerprint)
+	require.Equal(t, cities.StructureFingerprint(), stats.StructureFingerprint)
+}
+
+func TestStatsSmallMap(t *testing.T) {
+	f, err := os.Open("maps/small.map")
+	require.NoError(t, err)
+	defer f.Close()
+
+	cities := NewCities()
+	err = cities.Parse(f)
+	require.NoError(t, err)
+
+	stats := cities.Stats()
+	require.Equal(t, 12, stats.Cities)
+	require.Equal(t, 12, stats.Borders)
+	require.Equal(t, []int{12}, stats.Components)
+	require.Equal(t, 7, stats.Diameter)
+
+	var buf bytes.Buffer
+	stats.Print(&buf)
+	require.Contains(t, buf.String(), "components:           1 (12)\n")
+}
+
+func TestStatsEmpty(t *testing.T) {
+	stats := NewCities().Stats()
+	require.Equal(t, 0, stats.Cities)
+	require.Equal(t, 0, stats.Diameter)
+	require.Equal(t, 0.0, stats.AveragePathLength)
+}
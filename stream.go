@@ -0,0 +1,297 @@
+package invader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// StreamGenerator is a Generator that can also write the map while building
+// it, one city line at a time, without building the Cities in memory. Grids
+// are built row by row, only holding a couple of rows at once, so maps of
+// millions of cities can be generated. The output is the same as printing
+// the map built by Generate with the same seed.
+type StreamGenerator interface {
+	Generator
+	Stream(w io.Writer, r *Rand) error
+}
+
+// rowBuilder decides the borders of a grid one row at a time. When called
+// for row y, north holds the borders toward the previous row, and east and
+// south are cleared: east[x] tells whether the city at x borders its eastern
+// neighbour, south[x] whether it borders its southern neighbour. For a
+// torus, the builder decides the borders wrapping around the grid vertically
+// by filling north for the first row.
+type rowBuilder func(y int, north, east, south []bool)
+
+// buildRows calls the builder for every row of the grid and the emit
+// function for every city, in the order of the rows, with the index of its
+// neighbours in the order of AllDirections, -1 if there is none. Indexes are
+// the same as cityGrid ones.
+func (shape GridShape) buildRows(build rowBuilder, emit func(i int, neighbors [4]int) error) error {
+	if shape.Width <= 0 || shape.Height <= 0 {
+		return fmt.Errorf("grid dimensions must be positive: %dx%d", shape.Width, shape.Height)
+	}
+
+	if shape.Torus && (shape.Width < 3 || shape.Height < 3) {
+		return fmt.Errorf("torus dimensions must be at least 3x3: %dx%d", shape.Width, shape.Height)
+	}
+
+	w, h := shape.Width, shape.Height
+	grid := cityGrid{GridShape: shape}
+	north, east, south := make([]bool, w), make([]bool, w), make([]bool, w)
+	var wrap []bool
+	for y := 0; y < h; y++ {
+		for x := range east {
+			east[x], south[x] = false, false
+		}
+
+		build(y, north, east, south)
+		switch {
+		case !shape.Torus && y == h-1:
+			for x := range south {
+				south[x] = false
+			}
+		case shape.Torus && y == 0:
+			wrap = append(wrap, north...)
+		case shape.Torus && y == h-1:
+			copy(south, wrap)
+		}
+
+		if !shape.Torus {
+			east[w-1] = false
+		}
+
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			neighbors := [4]int{-1, -1, -1, -1}
+			west := x > 0 && east[x-1] || x == 0 && shape.Torus && east[w-1]
+			for d, border := range []bool{north[x], east[x], west, south[x]} {
+				if border {
+					neighbors[d] = grid.neighbor(i, AllDirections[d])
+				}
+			}
+
+			if err := emit(i, neighbors); err != nil {
+				return err
+			}
+		}
+
+		north, south = south, north
+	}
+
+	return nil
+}
+
+// rowGenerator is implemented by the generators building grids row by row.
+type rowGenerator interface {
+	// rows checks the generator settings and returns its row builder.
+	rows(r *Rand) (GridShape, rowBuilder, error)
+}
+
+// streamRows writes the grid built by the generator as a text map, naming
+// the cities with the given function.
+func streamRows(w io.Writer, g rowGenerator, r *Rand, name func(i int) string) error {
+	shape, build, err := g.rows(r)
+	if err != nil {
+		return err
+	}
+
+	bw := bufio.NewWriter(w)
+	line := make([]byte, 0, 128)
+	err = shape.buildRows(build, func(i int, neighbors [4]int) error {
+		line = append(line[:0], quoteName(name(i))...)
+		for d, j := range neighbors {
+			if j >= 0 {
+				line = append(line, ' ')
+				line = append(line, AllDirections[d]...)
+				line = append(line, '=')
+				line = append(line, quoteName(name(j))...)
+			}
+		}
+
+		line = append(line, '\n')
+		_, err := bw.Write(line)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return bw.Flush()
+}
+
+// generateRows populates the cities with the grid built by the generator.
+func generateRows(cs Cities, g rowGenerator, r *Rand) error {
+	shape, build, err := g.rows(r)
+	if err != nil {
+		return err
+	}
+
+	return shape.buildRows(build, func(i int, neighbors [4]int) error {
+		city := cs.GetOrCreate(cityName(i))
+		for d, j := range neighbors {
+			if j >= 0 {
+				city.SetDirection(AllDirections[d], cs.GetOrCreate(cityName(j)))
+			}
+		}
+
+		return nil
+	})
+}
+
+// gridRows builds every possible border.
+func (g GridGenerator) gridRows(y int, north, east, south []bool) {
+	for x := range east {
+		east[x], south[x] = true, true
+		if y == 0 {
+			north[x] = g.Torus
+		}
+	}
+}
+
+func (g GridGenerator) rows(r *Rand) (GridShape, rowBuilder, error) {
+	return g.GridShape, g.gridRows, nil
+}
+
+func (g GridGenerator) Stream(w io.Writer, r *Rand) error {
+	return streamRows(w, g, r, cityName)
+}
+
+// percolationRows decides each border with the given probability.
+func (g PercolationGenerator) percolationRows(r *Rand) rowBuilder {
+	return func(y int, north, east, south []bool) {
+		if y == 0 && g.Torus {
+			for x := range north {
+				north[x] = r.Float64() < g.Density
+			}
+		}
+
+		for x := range east {
+			if x < g.Width-1 || g.Torus {
+				east[x] = r.Float64() < g.Density
+			}
+			if y < g.Height-1 {
+				south[x] = r.Float64() < g.Density
+			}
+		}
+	}
+}
+
+func (g PercolationGenerator) rows(r *Rand) (GridShape, rowBuilder, error) {
+	if g.Density < 0 || g.Density > 1 {
+		return g.GridShape, nil, fmt.Errorf("density must be between 0 and 1: %g", g.Density)
+	}
+
+	return g.GridShape, g.percolationRows(r), nil
+}
+
+func (g PercolationGenerator) Stream(w io.Writer, r *Rand) error {
+	return streamRows(w, g, r, cityName)
+}
+
+// ellerRows carves a perfect maze row by row using Eller's algorithm: cells
+// of a row are randomly joined with their eastern neighbour if they are not
+// already connected, then every set of connected cells goes down to the next
+// row through at least one cell. The last row joins every remaining set.
+func ellerRows(shape GridShape, r *Rand) rowBuilder {
+	w := shape.Width
+	sets := make([]int, w)   // set of each cell of the current row
+	parent := make([]int, w) // union-find of the sets of the current row
+	members := make([][]int, w)
+	next := 0 // first unused set
+	for x := range sets {
+		sets[x] = -1
+	}
+
+	var find func(s int) int
+	find = func(s int) int {
+		for parent[s] != s {
+			parent[s] = parent[parent[s]]
+			s = parent[s]
+		}
+		return s
+	}
+
+	return func(y int, north, east, south []bool) {
+		// Number the sets coming from the previous row from 0, and give a new
+		// set to the other cells
+		renumber := make(map[int]int)
+		for x := range sets {
+			if sets[x] >= 0 {
+				s, ok := renumber[sets[x]]
+				if !ok {
+					s = len(renumber)
+					renumber[sets[x]] = s
+				}
+				sets[x] = s
+			}
+		}
+
+		next = len(renumber)
+		for x := range sets {
+			if sets[x] < 0 {
+				sets[x] = next
+				next++
+			}
+			parent[x] = x
+		}
+
+		last := y == shape.Height-1
+		for x := 0; x < w-1; x++ {
+			a, b := find(sets[x]), find(sets[x+1])
+			if a != b && (last || r.Intn(2) == 0) {
+				east[x] = true
+				parent[b] = a
+			}
+		}
+
+		for x := range sets {
+			sets[x] = find(sets[x])
+		}
+
+		if last {
+			return
+		}
+
+		for s := range members {
+			members[s] = members[s][:0]
+		}
+		for x, s := range sets {
+			members[s] = append(members[s], x)
+		}
+
+		for s, cells := range members {
+			if len(cells) == 0 {
+				continue
+			}
+
+			// One random cell goes down for sure, the others maybe
+			down := cells[r.Intn(len(cells))]
+			for _, x := range cells {
+				south[x] = x == down || r.Intn(2) == 0
+				if !south[x] {
+					sets[x] = -1
+				} else {
+					sets[x] = s
+				}
+			}
+		}
+	}
+}
+
+func (g MazeGenerator) rows(r *Rand) (GridShape, rowBuilder, error) {
+	if g.Algorithm != MazeEller {
+		return g.GridShape, nil, fmt.Errorf("maze algorithm `%s` cannot be streamed", g.Algorithm)
+	}
+
+	if g.Torus {
+		return g.GridShape, nil, fmt.Errorf("maze algorithm `%s` doesn't support tori", g.Algorithm)
+	}
+
+	return g.GridShape, ellerRows(g.GridShape, r), nil
+}
+
+func (g MazeGenerator) Stream(w io.Writer, r *Rand) error {
+	return streamRows(w, g, r, cityName)
+}
@@ -0,0 +1,78 @@
+package invader
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStream(t *testing.T) {
+	rectangle := GridShape{Width: 7, Height: 5}
+	torus := GridShape{Width: 7, Height: 5, Torus: true}
+
+	testCases := []struct {
+		Name      string
+		Generator StreamGenerator
+	}{
+		{Name: "grid", Generator: GridGenerator{GridShape: rectangle}},
+		{Name: "torus grid", Generator: GridGenerator{GridShape: torus}},
+		{Name: "percolation", Generator: PercolationGenerator{GridShape: rectangle, Density: 0.5}},
+		{Name: "torus percolation", Generator: PercolationGenerator{GridShape: torus, Density: 0.5}},
+		{Name: "eller maze", Generator: MazeGenerator{GridShape: rectangle, Algorithm: MazeEller}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			var streamed bytes.Buffer
+			err := tc.Generator.Stream(&streamed, NewRand(42))
+			require.NoError(t, err)
+
+			cities := NewCities()
+			err = tc.Generator.Generate(cities, NewRand(42))
+			require.NoError(t, err)
+
+			var printed bytes.Buffer
+			cities.Print(&printed)
+			require.Equal(t, printed.String(), streamed.String())
+
+			// The streamed map can be read back
+			parsed := NewCities()
+			err = parsed.Parse(&streamed, WithStrict())
+			require.NoError(t, err)
+			require.Len(t, parsed, 35)
+		})
+	}
+}
+
+func TestStreamEllerMaze(t *testing.T) {
+	for seed := uint64(0); seed < 20; seed++ {
+		cities := NewCities()
+		err := MazeGenerator{GridShape: GridShape{Width: 9, Height: 6}, Algorithm: MazeEller}.Generate(cities, NewRand(seed))
+		require.NoError(t, err)
+
+		// A perfect maze is a spanning tree of the grid
+		stats := cities.Stats()
+		require.Equal(t, 54, stats.Cities)
+		require.Equal(t, 53, stats.Borders)
+		require.Len(t, stats.Components, 1)
+	}
+}
+
+func TestStreamLarge(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping large map generation in short mode")
+	}
+
+	g := MazeGenerator{GridShape: GridShape{Width: 1000, Height: 500}, Algorithm: MazeEller}
+	err := g.Stream(io.Discard, NewRand(1))
+	require.NoError(t, err)
+}
+
+func TestStreamError(t *testing.T) {
+	r := NewRand(1)
+	require.Error(t, MazeGenerator{GridShape: GridShape{Width: 4, Height: 4}, Algorithm: MazeDFS}.Stream(io.Discard, r))
+	require.Error(t, MazeGenerator{GridShape: GridShape{Width: 4, Height: 4, Torus: true}, Algorithm: MazeEller}.Stream(io.Discard, r))
+	require.Error(t, GridGenerator{GridShape: GridShape{Width: 0, Height: 4}}.Stream(io.Discard, r))
+}
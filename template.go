@@ -0,0 +1,140 @@
+package invader
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+)
+
+// Characters of a map template.
+const (
+	templateCity      = '#'
+	templateRoadEast  = '-'
+	templateRoadSouth = '|'
+	templateEmpty     = ' '
+	templateEmptyDot  = '.'
+	templateComment   = ';'
+)
+
+// Template is a map drawn as ASCII art, the first line being the northern
+// one:
+//
+//	#-#   #
+//	|     |
+//	#-#-#-#
+//
+// Each `#` is a city. Cities next to each other border each other, and so do
+// cities joined by a road of `-` (east-west) or `|` (north-south). Spaces and
+// dots are empty land, and lines starting with `;` are comments. Cities are
+// named `city_<n>` in reading order.
+type Template struct {
+	rows  [][]rune
+	lines []int // line number of each row, comments being skipped
+}
+
+// ReadTemplate reads and checks a map template.
+func ReadTemplate(r io.Reader) (*Template, error) {
+	t := &Template{}
+	hasCity := false
+	scanner := bufio.NewScanner(r)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		line := []rune(scanner.Text())
+		if len(line) > 0 && line[0] == templateComment {
+			continue
+		}
+
+		for col, c := range line {
+			switch c {
+			case templateCity:
+				hasCity = true
+			case templateRoadEast, templateRoadSouth, templateEmpty, templateEmptyDot:
+			default:
+				return nil, fmt.Errorf("line %d, column %d: invalid template character %q", lineno, col+1, c)
+			}
+		}
+
+		t.rows = append(t.rows, line)
+		t.lines = append(t.lines, lineno)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("unable to read template: %w", err)
+	}
+
+	// An empty map cannot be invaded
+	if !hasCity {
+		return nil, fmt.Errorf("template has no city")
+	}
+
+	// Every road must join two cities
+	for y, row := range t.rows {
+		for x, c := range row {
+			var dx, dy int
+			switch c {
+			case templateRoadEast:
+				dx = 1
+			case templateRoadSouth:
+				dy = 1
+			default:
+				continue
+			}
+
+			if t.follow(x, y, -dx, -dy, c) < 0 || t.follow(x, y, dx, dy, c) < 0 {
+				return nil, fmt.Errorf("line %d, column %d: road %q doesn't join two cities", t.lines[y], x+1, c)
+			}
+		}
+	}
+
+	return t, nil
+}
+
+func (t *Template) at(x, y int) rune {
+	if y < 0 || y >= len(t.rows) || x < 0 || x >= len(t.rows[y]) {
+		return templateEmpty
+	}
+
+	return t.rows[y][x]
+}
+
+// follow walks from (x, y) in the given direction over road characters and
+// returns the number of steps to the city it reaches, or -1 if there is none.
+func (t *Template) follow(x, y, dx, dy int, road rune) int {
+	for steps := 1; ; steps++ {
+		x, y = x+dx, y+dy
+		switch t.at(x, y) {
+		case templateCity:
+			return steps
+		case road:
+		default:
+			return -1
+		}
+	}
+}
+
+// Generate populates the cities drawn on the template, the random generator
+// is not used.
+func (t *Template) Generate(cs Cities, r *Rand) error {
+	type cell struct {
+		x, y int
+	}
+
+	cities := make(map[cell]*City)
+	for y, row := range t.rows {
+		for x, c := range row {
+			if c == templateCity {
+				cities[cell{x, y}] = cs.GetOrCreate(cityName(len(cities)))
+			}
+		}
+	}
+
+	for c, city := range cities {
+		if steps := t.follow(c.x, c.y, 1, 0, templateRoadEast); steps > 0 {
+			city.SetDirection(East, cities[cell{c.x + steps, c.y}])
+		}
+		if steps := t.follow(c.x, c.y, 0, 1, templateRoadSouth); steps > 0 {
+			city.SetDirection(South, cities[cell{c.x, c.y + steps}])
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,68 @@
+package invader
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTemplate(t *testing.T) {
+	template := strings.Join([]string{
+		"; two islands joined by a bridge",
+		"##..#-#",
+		"##--#.|",
+		"....#-#",
+	}, "\n")
+
+	tmpl, err := ReadTemplate(strings.NewReader(template))
+	require.NoError(t, err)
+
+	cities := NewCities()
+	err = tmpl.Generate(cities, NewRand(1))
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	cities.Print(&buf)
+	require.Equal(t, strings.Join([]string{
+		"city_1 east=city_2 south=city_5",
+		"city_2 west=city_1 south=city_6",
+		"city_3 east=city_4 south=city_7",
+		"city_4 west=city_3 south=city_9",
+		"city_5 north=city_1 east=city_6",
+		"city_6 north=city_2 east=city_7 west=city_5",
+		"city_7 north=city_3 west=city_6 south=city_8",
+		"city_8 north=city_7 east=city_9",
+		"city_9 north=city_4 west=city_8",
+		"",
+	}, "\n"), buf.String())
+
+	critical := cities.Critical()
+	require.Len(t, critical.Bridges, 1)
+	require.Equal(t, "`city_6` east=`city_7`", critical.Bridges[0].String())
+}
+
+func TestTemplateError(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Template string
+		Want     string
+	}{
+		{Name: "invalid character", Template: "#-#\n#x#", Want: "line 2, column 2"},
+		{Name: "dangling road", Template: "#--", Want: "line 1, column 2"},
+		{Name: "road toward nothing", Template: "#\n|\n\n#", Want: "line 2, column 1"},
+		{Name: "crossing roads", Template: "#.#\n-|-\n#.#", Want: "line 2, column 1"},
+		{Name: "after a comment", Template: "; roads\n#-#\n|\n", Want: "line 3, column 1"},
+		{Name: "no city", Template: "; empty\n...\n", Want: "template has no city"},
+		{Name: "empty", Template: "", Want: "template has no city"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			_, err := ReadTemplate(strings.NewReader(tc.Template))
+			require.Error(t, err)
+			require.Contains(t, err.Error(), tc.Want)
+		})
+	}
+}